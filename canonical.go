@@ -0,0 +1,191 @@
+package snapshot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+/*
+Canonical signing encoding
+
+Every structure that gets signed is reduced to a byte string with the
+rules below before it is hashed. The encoding is only used to compute
+digests, the wire format is still protobuf. Test vectors that other
+implementations can check themselves against live in
+testdata/canonical_vectors.json.
+
+  - A message is the concatenation of its fields in ascending field
+    number order. Every field is written, including fields that hold
+    their default value. Field numbers match snapshot.proto.
+  - A field is its field number as a 4 byte big-endian unsigned integer
+    followed by its value.
+  - string: 4 byte big-endian length followed by the UTF-8 bytes.
+    Invalid UTF-8 is rejected.
+  - bytes: 4 byte big-endian length followed by the raw bytes.
+  - int32: 4 byte big-endian two's complement.
+  - uint64: 8 byte big-endian.
+  - double: 8 byte big-endian IEEE 754 binary64. Negative zero is
+    written as positive zero. NaN and infinities are rejected.
+  - repeated: 4 byte big-endian element count followed by each element
+    in order. Order is significant.
+  - message: 4 byte big-endian length followed by the encoded message.
+    An unset message is encoded the same as a message holding only
+    default values.
+
+A signing digest is the hash of a domain label, written as a string
+value, followed by the canonical message. Transactions use the label
+TransactionDomain, epoch attestations use EpochDomain, key rotations use
+RotationDomain and revocation lists use RevocationDomain.
+*/
+
+// Interface for any datatype that has a canonical signing encoding
+type canonicalMarshaler interface {
+	MarshalCanonical() ([]byte, error)
+}
+
+/* canonicalEncoder builds a canonical encoding field by field. The
+first error encountered is kept and every later write is skipped */
+type canonicalEncoder struct {
+	buf []byte
+	err error
+}
+
+// bytes returns the encoded message or the first error encountered
+func (ce *canonicalEncoder) bytes() ([]byte, error) {
+	if ce.err != nil {
+		return nil, ce.err
+	}
+	return ce.buf, nil
+}
+
+func (ce *canonicalEncoder) putUint32(v uint32) {
+	var raw [4]byte
+	binary.BigEndian.PutUint32(raw[:], v)
+	ce.buf = append(ce.buf, raw[:]...)
+}
+
+func (ce *canonicalEncoder) putLengthPrefixed(b []byte) {
+	ce.putUint32(uint32(len(b)))
+	ce.buf = append(ce.buf, b...)
+}
+
+func (ce *canonicalEncoder) putStringValue(s string) {
+	if !utf8.ValidString(s) {
+		ce.err = &MarshalErr{simpleErr{err: fmt.Errorf("%q", s), msg: "Invalid UTF-8 in canonical string"}}
+		return
+	}
+	ce.putLengthPrefixed([]byte(s))
+}
+
+// putString writes a string field
+func (ce *canonicalEncoder) putString(num uint32, s string) {
+	if ce.err != nil {
+		return
+	}
+	ce.putUint32(num)
+	ce.putStringValue(s)
+}
+
+// putBytes writes a bytes field
+func (ce *canonicalEncoder) putBytes(num uint32, b []byte) {
+	if ce.err != nil {
+		return
+	}
+	ce.putUint32(num)
+	ce.putLengthPrefixed(b)
+}
+
+// putInt32 writes an int32 field
+func (ce *canonicalEncoder) putInt32(num uint32, v int32) {
+	if ce.err != nil {
+		return
+	}
+	ce.putUint32(num)
+	ce.putUint32(uint32(v))
+}
+
+// putUint64 writes a uint64 field
+func (ce *canonicalEncoder) putUint64(num uint32, v uint64) {
+	if ce.err != nil {
+		return
+	}
+	var raw [8]byte
+	binary.BigEndian.PutUint64(raw[:], v)
+	ce.putUint32(num)
+	ce.buf = append(ce.buf, raw[:]...)
+}
+
+// putDouble writes a double field
+func (ce *canonicalEncoder) putDouble(num uint32, v float64) {
+	if ce.err != nil {
+		return
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		ce.err = &MarshalErr{simpleErr{err: fmt.Errorf("%v", v), msg: "Non-finite double in canonical encoding"}}
+		return
+	}
+	if v == 0 {
+		v = 0 // Drops the sign of negative zero
+	}
+	var raw [8]byte
+	binary.BigEndian.PutUint64(raw[:], math.Float64bits(v))
+	ce.putUint32(num)
+	ce.buf = append(ce.buf, raw[:]...)
+}
+
+// putStrings writes a repeated string field
+func (ce *canonicalEncoder) putStrings(num uint32, ss []string) {
+	if ce.err != nil {
+		return
+	}
+	ce.putUint32(num)
+	ce.putUint32(uint32(len(ss)))
+	for _, s := range ss {
+		ce.putStringValue(s)
+	}
+}
+
+// putBytesList writes a repeated bytes field
+func (ce *canonicalEncoder) putBytesList(num uint32, bs [][]byte) {
+	if ce.err != nil {
+		return
+	}
+	ce.putUint32(num)
+	ce.putUint32(uint32(len(bs)))
+	for _, b := range bs {
+		ce.putLengthPrefixed(b)
+	}
+}
+
+// putMessage writes a nested message field
+func (ce *canonicalEncoder) putMessage(num uint32, m canonicalMarshaler) {
+	if ce.err != nil {
+		return
+	}
+	encoded, err := m.MarshalCanonical()
+	if err != nil {
+		ce.err = err
+		return
+	}
+	ce.putUint32(num)
+	ce.putLengthPrefixed(encoded)
+}
+
+// putMessages writes a repeated message field
+func (ce *canonicalEncoder) putMessages(num uint32, ms []canonicalMarshaler) {
+	if ce.err != nil {
+		return
+	}
+	ce.putUint32(num)
+	ce.putUint32(uint32(len(ms)))
+	for _, m := range ms {
+		encoded, err := m.MarshalCanonical()
+		if err != nil {
+			ce.err = err
+			return
+		}
+		ce.putLengthPrefixed(encoded)
+	}
+}
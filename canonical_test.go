@@ -0,0 +1,194 @@
+package snapshot
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+)
+
+type canonicalVectorFile struct {
+	Vectors []canonicalVector `json:"vectors"`
+}
+
+type canonicalVector struct {
+	Name         string              `json:"name"`
+	Type         string              `json:"type"`
+	Transaction  *vectorTransaction  `json:"transaction"`
+	EpochTriplet *vectorEpochTriplet `json:"epoch_triplet"`
+	Attestation  *vectorAttestation  `json:"epoch_attestation"`
+	Rotation     *vectorKeyRotation  `json:"key_rotation"`
+	Revocations  *vectorRevocations  `json:"revocation_list"`
+	Canonical    string              `json:"canonical"`
+	SHA256       string              `json:"sha256"`
+	Domain       string              `json:"domain"`
+	Signing      string              `json:"signing_digest"`
+	TxId         string              `json:"transaction_id"`
+}
+
+type vectorTransaction struct {
+	Id         string   `json:"id"`
+	Action     int32    `json:"action"`
+	Reward     float64  `json:"reward"`
+	Exchange   float64  `json:"exchange"`
+	Gainer     string   `json:"gainer"`
+	Loser      string   `json:"loser"`
+	Bystanders []string `json:"bystanders"`
+	Network    string   `json:"network"`
+}
+
+type vectorEpochTriplet struct {
+	Id      string  `json:"id"`
+	Epoch   int32   `json:"epoch"`
+	Balance float64 `json:"balance"`
+}
+
+type vectorAttestation struct {
+	EpochTriplet      vectorEpochTriplet `json:"epoch_triplet"`
+	Hash              string             `json:"hash"`
+	TransactionDigest string             `json:"transaction_digest"`
+	Network           string             `json:"network"`
+}
+
+type vectorKeyRotation struct {
+	Id     string `json:"id"`
+	Epoch  int32  `json:"epoch"`
+	NewKey string `json:"new_key"`
+	Hash   string `json:"hash"`
+}
+
+type vectorRevocations struct {
+	Version uint64 `json:"version"`
+	Entries []struct {
+		Id     string `json:"id"`
+		Epoch  int32  `json:"epoch"`
+		Reason string `json:"reason"`
+	} `json:"entries"`
+	Hash string `json:"hash"`
+}
+
+func (v *canonicalVector) build(t *testing.T) canonicalMarshaler {
+	switch v.Type {
+	case "transaction":
+		vt := v.Transaction
+		return &SimpleTransaction{protoTransaction: &Transaction{
+			Id: vt.Id, Action: vt.Action, Reward: vt.Reward, Exchange: vt.Exchange,
+			Gainer: vt.Gainer, Loser: vt.Loser, Bystanders: vt.Bystanders, Network: vt.Network,
+		}}
+	case "epoch_triplet":
+		ve := v.EpochTriplet
+		return NewSimpleEpochTriplet(ve.Id, ve.Epoch, ve.Balance)
+	case "epoch_attestation":
+		va := v.Attestation
+		txDigest, err := hex.DecodeString(va.TransactionDigest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		triplet := NewSimpleEpochTriplet(va.EpochTriplet.Id, va.EpochTriplet.Epoch, va.EpochTriplet.Balance)
+		return &epochAttestation{triplet: triplet, hashID: va.Hash, txDigest: txDigest, network: va.Network}
+	case "key_rotation":
+		vr := v.Rotation
+		newKey, err := hex.DecodeString(vr.NewKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &SimpleKeyRotation{protoKeyRotation: &KeyRotation{
+			Id: vr.Id, Epoch: vr.Epoch, NewKey: newKey, Hash: vr.Hash, Sign: []byte("not signed"),
+		}}
+	case "revocation_list":
+		list := NewSimpleRevocationList(v.Revocations.Version)
+		for _, entry := range v.Revocations.Entries {
+			list.Revoke(entry.Id, entry.Epoch, entry.Reason)
+		}
+		list.protoRevocationList.Hash = v.Revocations.Hash
+		list.protoRevocationList.Sign = []byte("not signed")
+		return list
+	}
+	t.Fatalf("unknown vector type %q", v.Type)
+	return nil
+}
+
+func loadCanonicalVectors(t *testing.T) []canonicalVector {
+	raw, err := os.ReadFile("testdata/canonical_vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file canonicalVectorFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		t.Fatal(err)
+	}
+	return file.Vectors
+}
+
+func TestCanonicalVectors(t *testing.T) {
+	for _, v := range loadCanonicalVectors(t) {
+		encoded, err := v.build(t).MarshalCanonical()
+		if err != nil {
+			t.Fatalf("%s: %v", v.Name, err)
+		}
+		if got := hex.EncodeToString(encoded); got != v.Canonical {
+			t.Errorf("%s: canonical encoding\n got  %s\n want %s", v.Name, got, v.Canonical)
+		}
+		sum := sha256.Sum256(encoded)
+		if got := hex.EncodeToString(sum[:]); got != v.SHA256 {
+			t.Errorf("%s: sha256 got %s want %s", v.Name, got, v.SHA256)
+		}
+		if v.TxId != "" {
+			tx := v.build(t).(*SimpleTransaction)
+			if got, err := tx.ComputeId(crypto.SHA256); err != nil || got != v.TxId {
+				t.Errorf("%s: transaction id got %s (%v) want %s", v.Name, got, err, v.TxId)
+			}
+		}
+		if v.Domain == "" {
+			continue
+		}
+		digest, err := digestMarshaler(v.build(t), v.Domain, crypto.SHA256)
+		if err != nil {
+			t.Fatalf("%s: %v", v.Name, err)
+		}
+		if got := hex.EncodeToString(digest); got != v.Signing {
+			t.Errorf("%s: signing digest got %s want %s", v.Name, got, v.Signing)
+		}
+	}
+}
+
+func TestCanonicalRejectsNonFinite(t *testing.T) {
+	for _, balance := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if _, err := NewSimpleEpochTriplet("0", 1, balance).MarshalCanonical(); err == nil {
+			t.Errorf("balance %v was encoded", balance)
+		}
+	}
+	tx := createTransaction(1, 0, 0, "\xff", "ID2")
+	if _, err := tx.MarshalCanonical(); err == nil {
+		t.Error("invalid UTF-8 was encoded")
+	}
+}
+
+func TestCanonicalIgnoresWireEncoding(t *testing.T) {
+	tx := createTransaction(3, 0.25, 9, "ID1", "ID2")
+	tx.SetBystanders([]string{"a", "b"})
+	before, err := tx.MarshalCanonical()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := tx.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &SimpleTransaction{}
+	if err := decoded.Unmarshal(raw); err != nil {
+		t.Fatal(err)
+	}
+	after, err := decoded.MarshalCanonical()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Fatal("canonical encoding changed after a wire round trip")
+	}
+}
@@ -0,0 +1,95 @@
+package snapshot
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+)
+
+/* CertificateFetcher looks up the certificate chain a proof refers to
+with its certificate reference. The chain starts with the leaf */
+type CertificateFetcher interface {
+	FetchCertificates(ctx context.Context, ref string) ([]*x509.Certificate, error)
+}
+
+/* CertificateVerifier establishes signer identity with X.509
+certificates instead of a key map. A proof is checked with the key of
+the leaf certificate it carries or refers to once the chain validates
+against Roots and the leaf was issued to the proof's Node Id */
+type CertificateVerifier struct {
+	// Roots holds the trusted CA certificates. It must be set
+	Roots *x509.CertPool
+	// Fetcher resolves certificate references. Without it they are rejected
+	Fetcher CertificateFetcher
+	/* NodeId returns the Node Id a leaf certificate was issued to. It
+	defaults to the certificate's subject common name */
+	NodeId func(cert *x509.Certificate) string
+	// KeyUsages restricts the leaf's extended key usages. It defaults to any
+	KeyUsages []x509.ExtKeyUsage
+	/* CurrentTime is the time chains are validated at. It defaults to now,
+	set it to check archived snapshots whose certificates have expired */
+	CurrentTime time.Time
+}
+
+/* proofKey validates the certificate chain of a proof and returns the
+public key of its leaf */
+func (cv *CertificateVerifier) proofKey(ctx context.Context, proof *SimpleProofTuple, id string) (crypto.PublicKey, error) {
+	if cv.Roots == nil {
+		return nil, certificateErr(id, errors.New("no root certificates configured"))
+	}
+	chain, err := proof.GetCertificates()
+	if err != nil {
+		return nil, certificateErr(id, err)
+	}
+	if ref := proof.GetCertificateRef(); len(chain) == 0 && ref != "" {
+		if cv.Fetcher == nil {
+			return nil, certificateErr(id, fmt.Errorf("can't fetch certificate reference %q", ref))
+		}
+		if chain, err = cv.Fetcher.FetchCertificates(ctx, ref); err != nil {
+			return nil, certificateErr(id, err)
+		}
+	}
+	if len(chain) == 0 {
+		return nil, certificateErr(id, errors.New("proof carries no certificate"))
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	usages := cv.KeyUsages
+	if len(usages) == 0 {
+		usages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+	leaf := chain[0]
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         cv.Roots,
+		Intermediates: intermediates,
+		CurrentTime:   cv.CurrentTime,
+		KeyUsages:     usages,
+	})
+	if err != nil {
+		return nil, certificateErr(id, err)
+	}
+
+	if subject := cv.nodeId(leaf); subject != id {
+		return nil, certificateErr(id, fmt.Errorf("certificate was issued to %q", subject))
+	}
+	return leaf.PublicKey, nil
+}
+
+// nodeId returns the Node Id a leaf certificate was issued to
+func (cv *CertificateVerifier) nodeId(cert *x509.Certificate) string {
+	if cv.NodeId != nil {
+		return cv.NodeId(cert)
+	}
+	return cert.Subject.CommonName
+}
+
+// certificateErr builds a CertificateErr for node id
+func certificateErr(id string, err error) error {
+	return &CertificateErr{simpleErr{err: err, msg: fmt.Sprintf("Certificate of %q rejected", id)}}
+}
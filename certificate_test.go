@@ -0,0 +1,138 @@
+package snapshot
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type mapCertificateFetcher map[string][]*x509.Certificate
+
+func (mf mapCertificateFetcher) FetchCertificates(ctx context.Context, ref string) ([]*x509.Certificate, error) {
+	chain, ok := mf[ref]
+	if !ok {
+		return nil, errors.New("unknown reference")
+	}
+	return chain, nil
+}
+
+/* issueCertificate creates a certificate for name signed by parent, or a
+self-signed certificate when parent is nil */
+func issueCertificate(t *testing.T, name string, pub crypto.PublicKey, ca bool, parent *x509.Certificate,
+	parentKey crypto.Signer) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	if ca {
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestCertificateIdentity(t *testing.T) {
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	rootKey, interKey, rogueKey := newKey(), newKey(), newKey()
+	root := issueCertificate(t, "root", rootKey.Public(), true, nil, rootKey)
+	inter := issueCertificate(t, "intermediate", interKey.Public(), true, root, rootKey)
+	rogue := issueCertificate(t, "rogue", rogueKey.Public(), true, nil, rogueKey)
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	nodeKeys := []*ecdsa.PrivateKey{newKey(), newKey(), newKey()}
+	leaves := []*x509.Certificate{
+		issueCertificate(t, "node-0", nodeKeys[0].Public(), false, root, rootKey),
+		issueCertificate(t, "node-1", nodeKeys[1].Public(), false, inter, interKey),
+		issueCertificate(t, "node-2", nodeKeys[2].Public(), false, root, rootKey),
+	}
+	tx := createTransaction(1, 1, 10, "node-0", "node-1")
+	build := func(ids []string, chains [][]*x509.Certificate, refs []string) *SimpleSnapshot {
+		snapshot := NewSimpleSnapshot(tx)
+		for i, id := range ids {
+			tup, err := NewSimpleProofTuple(tx, id, 1, 5, nodeKeys[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			tup.SetCertificates(chains[i])
+			tup.SetCertificateRef(refs[i])
+			snapshot.AddProof(tup)
+		}
+		return snapshot
+	}
+
+	cv := &CertificateVerifier{
+		Roots:   roots,
+		Fetcher: mapCertificateFetcher{"cert://node-2": {leaves[2]}},
+	}
+	sv := &SnapshotVerifier{Pass: 1, Certificates: cv}
+	ids := []string{"node-0", "node-1", "node-2"}
+	snapshot := build(ids, [][]*x509.Certificate{{leaves[0]}, {leaves[1], inter}, nil},
+		[]string{"", "", "cert://node-2"})
+	if err := sv.Verify(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	// A chain issued to another node, a rogue CA and a missing chain fail
+	rogueLeaf := issueCertificate(t, "node-1", nodeKeys[1].Public(), false, rogue, rogueKey)
+	snapshot = build(ids, [][]*x509.Certificate{{leaves[1]}, {rogueLeaf, rogue}, nil},
+		[]string{"", "", "cert://unknown"})
+	report := sv.Report(snapshot)
+	var certErr *CertificateErr
+	for i, result := range report.Proofs {
+		if result.KeyFound || !errors.As(result.TransactionErr, &certErr) {
+			t.Errorf("proof %d: expected CertificateErr, got %v", i, result.TransactionErr)
+		}
+	}
+
+	// Expired chains still verify at the time the snapshot was made
+	cv.CurrentTime = time.Now().Add(2 * time.Hour)
+	snapshot = build(ids[:1], [][]*x509.Certificate{{leaves[0]}}, []string{""})
+	if err := sv.Verify(snapshot); err == nil {
+		t.Fatal("expired certificate was accepted")
+	}
+	cv.CurrentTime = time.Now()
+	if err := sv.Verify(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	// Swapping the chain of a proof doesn't make it a different proof
+	original := snapshot.GetProofs()[0].protoProofTuple
+	copied := &SimpleProofTuple{protoProofTuple: proto.Clone(original).(*Snapshot_ProofTuple)}
+	copied.SetCertificates([]*x509.Certificate{leaves[1]})
+	var dup *DuplicateProofErr
+	if err := snapshot.AddProof(copied); !errors.As(err, &dup) {
+		t.Fatalf("expected DuplicateProofErr, got %v", err)
+	}
+}
@@ -44,3 +44,121 @@ type VerificationErr struct {
 type PassErr struct {
 	simpleErr
 }
+
+// HashErr is returned if a hash function is unknown or unsupported
+type HashErr struct {
+	simpleErr
+}
+
+// NetworkErr is returned if a snapshot belongs to a different network
+type NetworkErr struct {
+	simpleErr
+}
+
+// PolicyErr is returned if a snapshot does not meet a VerificationPolicy
+type PolicyErr struct {
+	simpleErr
+}
+
+// DuplicateProofErr is returned if a node adds the same proof twice
+type DuplicateProofErr struct {
+	simpleErr
+}
+
+/* ConflictingProofErr is returned if a node adds two different proofs
+to the same snapshot. Conflict holds both proofs as evidence */
+type ConflictingProofErr struct {
+	simpleErr
+	Conflict ProofConflict
+}
+
+// NoProofsErr is returned if a snapshot holds no proofs to verify
+type NoProofsErr struct {
+	simpleErr
+}
+
+/* UnknownSignerErr is returned if no public key is known for the node
+that made a proof */
+type UnknownSignerErr struct {
+	simpleErr
+}
+
+// KeyErr is returned if a public key could not be loaded
+type KeyErr struct {
+	simpleErr
+}
+
+/* RotationErr is returned if a key rotation is malformed, out of order
+or not signed by the key it replaces */
+type RotationErr struct {
+	simpleErr
+}
+
+/* RevokedSignerErr is returned if a proof was made with a key that a
+RevocationList distrusts. Entry holds the revocation that applied */
+type RevokedSignerErr struct {
+	simpleErr
+	Entry RevocationEntry
+}
+
+/* SchemeErr is returned if the signature scheme of a proof is unknown,
+not allowed or doesn't fit the signer's key */
+type SchemeErr struct {
+	simpleErr
+}
+
+/* MalformedSignatureErr is returned if a proof holds a signature that
+can't be decoded, as opposed to one that doesn't verify */
+type MalformedSignatureErr struct {
+	simpleErr
+}
+
+/* FingerprintErr is returned if a Node Id is not a valid key
+fingerprint or doesn't match the key of the node */
+type FingerprintErr struct {
+	simpleErr
+}
+
+/* CertificateErr is returned if a proof's certificate chain is missing,
+doesn't validate or was issued to another node */
+type CertificateErr struct {
+	simpleErr
+}
+
+/* EpochErr is returned if a node's epoch doesn't follow the last epoch
+it proved. Expected is the epoch the node should have proven */
+type EpochErr struct {
+	simpleErr
+	NodeId   string
+	Expected int32
+	Got      int32
+}
+
+// LedgerErr is returned if a transaction can't be applied to a Ledger
+type LedgerErr struct {
+	simpleErr
+}
+
+/* FraudProofErr is returned if a fraud proof is malformed or doesn't
+prove that its node equivocated */
+type FraudProofErr struct {
+	simpleErr
+}
+
+/* TransactionIdErr is returned if a transaction's id isn't the content
+address of the transaction */
+type TransactionIdErr struct {
+	simpleErr
+}
+
+/* ReplayErr is returned if a snapshot's transaction was already accepted
+by a ReplayGuard */
+type ReplayErr struct {
+	simpleErr
+}
+
+/* ReportErr is returned if a VerificationReport doesn't belong to the
+snapshot it is used with */
+type ReportErr struct {
+	simpleErr
+}
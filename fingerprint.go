@@ -0,0 +1,81 @@
+package snapshot
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+)
+
+/* multihashCodes maps the supported hash functions to their multihash
+function codes. Every code fits in a single varint byte */
+var multihashCodes = map[crypto.Hash]byte{
+	crypto.SHA256:   0x12,
+	crypto.SHA512:   0x13,
+	crypto.SHA3_256: 0x16,
+	crypto.SHA384:   0x20,
+}
+
+/* Fingerprint returns the self-certifying Node Id of a public key using
+SHA-256. See FingerprintWithHash */
+func Fingerprint(pk crypto.PublicKey) (string, error) {
+	return FingerprintWithHash(pk, crypto.SHA256)
+}
+
+/* FingerprintWithHash returns the self-certifying Node Id of a public
+key. The Id is the hex encoded multihash (function code, digest length
+and digest) of the key's PKIX DER encoding, so anyone holding the key
+can check that it belongs to the Id */
+func FingerprintWithHash(pk crypto.PublicKey, hash crypto.Hash) (string, error) {
+	fingerprint, err := fingerprint(pk, hash)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(fingerprint), nil
+}
+
+/* CheckFingerprint returns nil if id is the fingerprint of pk. A
+FingerprintErr is returned if id isn't a fingerprint or belongs to
+another key */
+func CheckFingerprint(id string, pk crypto.PublicKey) error {
+	raw, err := hex.DecodeString(id)
+	if err != nil || len(raw) < 2 || int(raw[1]) != len(raw)-2 {
+		return fingerprintErr(id, fmt.Errorf("not a key fingerprint"))
+	}
+	for hash, code := range multihashCodes {
+		if code != raw[0] {
+			continue
+		}
+		expected, err := fingerprint(pk, hash)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(expected, raw) {
+			return fingerprintErr(id, fmt.Errorf("fingerprint belongs to another key"))
+		}
+		return nil
+	}
+	return fingerprintErr(id, fmt.Errorf("unknown multihash code 0x%x", raw[0]))
+}
+
+// fingerprint returns the binary multihash of pk
+func fingerprint(pk crypto.PublicKey, hash crypto.Hash) ([]byte, error) {
+	code, ok := multihashCodes[hash]
+	if !ok || !hash.Available() {
+		return nil, &HashErr{simpleErr{err: fmt.Errorf("%v", hash), msg: "Hash can't be used for fingerprints"}}
+	}
+	der, err := x509.MarshalPKIXPublicKey(pk)
+	if err != nil {
+		return nil, &KeyErr{simpleErr{err: err, msg: "fingerprint()"}}
+	}
+
+	hasher := hash.New()
+	hasher.Write(der)
+	return append([]byte{code, byte(hasher.Size())}, hasher.Sum(nil)...), nil
+}
+
+// fingerprintErr builds a FingerprintErr for node id
+func fingerprintErr(id string, err error) error {
+	return &FingerprintErr{simpleErr{err: err, msg: fmt.Sprintf("Node Id %q", id)}}
+}
@@ -0,0 +1,202 @@
+package snapshot
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+/* SimpleFraudProof implements portable evidence that a node equivocated
+by signing two different transactions, or two different balances, for
+the same epoch. It holds both signed statements in full so anyone with
+the offender's public key can check it */
+type SimpleFraudProof struct {
+	protoFraudProof *FraudProof
+}
+
+/* newSimpleFraudProof returns a SimpleFraudProof made of two single
+proof statements */
+func newSimpleFraudProof(first *SimpleSnapshot, second *SimpleSnapshot) *SimpleFraudProof {
+	return &SimpleFraudProof{
+		protoFraudProof: &FraudProof{First: first.protoSnapshot, Second: second.protoSnapshot},
+	}
+}
+
+// Marshal serializes SimpleFraudProof into a slice of bytes
+func (sf *SimpleFraudProof) Marshal() ([]byte, error) {
+	out, err := proto.Marshal(sf.protoFraudProof)
+	if err != nil {
+		return out, &MarshalErr{simpleErr{err: err, msg: "SimpleFraudProof.Marshal()"}}
+	}
+	return out, nil
+}
+
+// Unmarshal deserializes SimpleFraudProof from a slice of bytes
+func (sf *SimpleFraudProof) Unmarshal(serial []byte) error {
+	sf.protoFraudProof = &FraudProof{}
+	if err := proto.Unmarshal(serial, sf.protoFraudProof); err != nil {
+		return &MarshalErr{simpleErr{err: err, msg: "SimpleFraudProof.Unmarshal()"}}
+	}
+	return nil
+}
+
+/* GetStatements returns both conflicting statements. Each one is a
+snapshot holding the single proof of the offending node */
+func (sf *SimpleFraudProof) GetStatements() (*SimpleSnapshot, *SimpleSnapshot) {
+	return &SimpleSnapshot{protoSnapshot: sf.protoFraudProof.GetFirst()},
+		&SimpleSnapshot{protoSnapshot: sf.protoFraudProof.GetSecond()}
+}
+
+/* GetEpoch returns the epoch the offending node signed twice as claimed
+by the first statement */
+func (sf *SimpleFraudProof) GetEpoch() *SimpleEpochTriplet {
+	proofs := sf.protoFraudProof.GetFirst().GetProofs()
+	if len(proofs) == 0 {
+		return &SimpleEpochTriplet{protoEpochTriplet: &Snapshot_ProofTuple_EpochTriplet{}}
+	}
+	return &SimpleEpochTriplet{protoEpochTriplet: proofs[0].GetEpoch()}
+}
+
+/* Verify checks that the fraud proof shows the owner of the provided
+public key signing two conflicting statements for the same epoch. A
+FraudProofErr is returned if it doesn't */
+func (sf *SimpleFraudProof) Verify(pk crypto.PublicKey, verf Verifier) error {
+	first, second := sf.GetStatements()
+	if len(first.GetProofs()) != 1 || len(second.GetProofs()) != 1 {
+		return fraudProofErr(errors.New("every statement must hold exactly one proof"))
+	}
+	a, b := first.GetProofs()[0].GetEpoch(), second.GetProofs()[0].GetEpoch()
+	if a.GetId() != b.GetId() || a.GetEpochNumber() != b.GetEpochNumber() {
+		return fraudProofErr(fmt.Errorf("statements are for %q epoch %d and %q epoch %d",
+			a.GetId(), a.GetEpochNumber(), b.GetId(), b.GetEpochNumber()))
+	}
+	conflict, err := conflicting(first, second)
+	if err != nil {
+		return fraudProofErr(err)
+	}
+	if !conflict {
+		return fraudProofErr(errors.New("statements don't conflict"))
+	}
+
+	for _, statement := range []*SimpleSnapshot{first, second} {
+		sv := &SnapshotVerifier{
+			Pass:     1,
+			Keys:     MapKeyResolver{a.GetId(): pk},
+			Verifier: verf,
+			Network:  statement.GetNetwork(),
+		}
+		if err := sv.Verify(statement); err != nil {
+			return fraudProofErr(err)
+		}
+	}
+	return nil
+}
+
+/* EquivocationDetector watches the proofs of snapshots and emits a
+SimpleFraudProof whenever a node signs a different transaction or
+balance for an epoch it already signed. It is safe for concurrent use */
+type EquivocationDetector struct {
+	verifier *SnapshotVerifier
+
+	mutex      sync.Mutex
+	statements map[nodeEpoch]*SimpleSnapshot
+}
+
+// nodeEpoch identifies a single epoch of a node
+type nodeEpoch struct {
+	id    string
+	epoch int32
+}
+
+/* NewEquivocationDetector returns an EquivocationDetector. If verifier
+is set only proofs it finds valid on their own are considered, so forged
+proofs can't frame a node or hide its real statement. Its Pass, Policy
+and Replay are ignored */
+func NewEquivocationDetector(verifier *SnapshotVerifier) *EquivocationDetector {
+	return &EquivocationDetector{verifier: verifier, statements: make(map[nodeEpoch]*SimpleSnapshot)}
+}
+
+/* Observe checks every proof of a snapshot against the statements seen
+so far and returns a SimpleFraudProof for each conflict it finds */
+func (ed *EquivocationDetector) Observe(snapshot *SimpleSnapshot) ([]*SimpleFraudProof, error) {
+	ed.mutex.Lock()
+	defer ed.mutex.Unlock()
+
+	var frauds []*SimpleFraudProof
+	for _, proof := range snapshot.GetProofs() {
+		statement := singleProofStatement(snapshot, proof)
+		if ed.verifier != nil {
+			sv := *ed.verifier
+			sv.Pass, sv.Policy, sv.Replay = 1, nil, nil
+			if err := sv.Verify(statement); err != nil {
+				continue
+			}
+		}
+
+		key := nodeEpoch{id: proof.GetEpoch().GetId(), epoch: proof.GetEpoch().GetEpochNumber()}
+		earlier, ok := ed.statements[key]
+		if !ok {
+			ed.statements[key] = statement
+			continue
+		}
+		conflict, err := conflicting(earlier, statement)
+		if err != nil {
+			return frauds, err
+		}
+		if conflict {
+			frauds = append(frauds, newSimpleFraudProof(earlier, statement))
+		}
+	}
+	return frauds, nil
+}
+
+/* Prune forgets every statement for epochs before epoch to bound the
+memory the detector uses */
+func (ed *EquivocationDetector) Prune(epoch int32) {
+	ed.mutex.Lock()
+	defer ed.mutex.Unlock()
+	for key := range ed.statements {
+		if key.epoch < epoch {
+			delete(ed.statements, key)
+		}
+	}
+}
+
+/* singleProofStatement returns a copy of snapshot holding only proof,
+which is everything needed to check the proof on its own */
+func singleProofStatement(snapshot *SimpleSnapshot, proof *SimpleProofTuple) *SimpleSnapshot {
+	return &SimpleSnapshot{
+		protoSnapshot: &Snapshot{
+			Transaction: proto.Clone(snapshot.protoSnapshot.GetTransaction()).(*Transaction),
+			Hash:        snapshot.protoSnapshot.GetHash(),
+			Network:     snapshot.GetNetwork(),
+			Proofs:      []*Snapshot_ProofTuple{proto.Clone(proof.protoProofTuple).(*Snapshot_ProofTuple)},
+		},
+	}
+}
+
+/* conflicting returns whether two single proof statements of the same
+epoch commit to a different transaction, network or balance. The same
+statement signed twice isn't a conflict */
+func conflicting(a *SimpleSnapshot, b *SimpleSnapshot) (bool, error) {
+	aTx, err := a.GetTransaction().MarshalCanonical()
+	if err != nil {
+		return false, err
+	}
+	bTx, err := b.GetTransaction().MarshalCanonical()
+	if err != nil {
+		return false, err
+	}
+	aBalance := a.GetProofs()[0].GetEpoch().GetBalance()
+	bBalance := b.GetProofs()[0].GetEpoch().GetBalance()
+	return !bytes.Equal(aTx, bTx) || a.GetNetwork() != b.GetNetwork() || aBalance != bBalance, nil
+}
+
+// fraudProofErr wraps err in a FraudProofErr
+func fraudProofErr(err error) error {
+	return &FraudProofErr{simpleErr{err: err, msg: "Invalid fraud proof"}}
+}
@@ -0,0 +1,71 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEquivocationDetector(t *testing.T) {
+	offender, other := testKey(t, 0), testKey(t, 1)
+	keys := MapKeyResolver{"0": &offender.PublicKey, "1": &other.PublicKey}
+	detector := NewEquivocationDetector(&SnapshotVerifier{Keys: keys, Verifier: pkcsVerifier})
+
+	observe := func(tx *SimpleTransaction, proof testProof) []*SimpleFraudProof {
+		frauds, err := detector.Observe(createSnapshot(t, tx, proof))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return frauds
+	}
+
+	txA := createTransaction(1, 1, 10, "0", "1")
+	txB := createTransaction(1, 1, 99, "0", "1")
+	if frauds := observe(txA, testProof{"0", 3, 10, offender}); len(frauds) != 0 {
+		t.Fatal("first statement reported as fraud")
+	}
+	// Signing the same statement again is not equivocation
+	if frauds := observe(txA, testProof{"0", 3, 10, offender}); len(frauds) != 0 {
+		t.Fatal("repeated statement reported as fraud")
+	}
+	// Forged proofs can't frame a node
+	if frauds := observe(txB, testProof{"0", 3, 10, other}); len(frauds) != 0 {
+		t.Fatal("forged statement reported as fraud")
+	}
+
+	frauds := append(observe(txB, testProof{"0", 3, 10, offender}), observe(txA, testProof{"0", 3, 11, offender})...)
+	if len(frauds) != 2 {
+		t.Fatalf("expected 2 fraud proofs, got %d", len(frauds))
+	}
+	for _, fraud := range frauds {
+		raw, err := fraud.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := &SimpleFraudProof{}
+		if err := decoded.Unmarshal(raw); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.GetEpoch().GetId() != "0" || decoded.GetEpoch().GetEpochNumber() != 3 {
+			t.Fatalf("unexpected epoch %v", decoded.GetEpoch())
+		}
+		if err := decoded.Verify(&offender.PublicKey, pkcsVerifier); err != nil {
+			t.Fatal(err)
+		}
+		var fraudErr *FraudProofErr
+		if err := decoded.Verify(&other.PublicKey, pkcsVerifier); !errors.As(err, &fraudErr) {
+			t.Fatalf("expected FraudProofErr for another key, got %v", err)
+		}
+	}
+
+	// Two copies of the same statement don't prove anything
+	first, _ := frauds[0].GetStatements()
+	same := newSimpleFraudProof(first, first)
+	if err := same.Verify(&offender.PublicKey, pkcsVerifier); err == nil {
+		t.Fatal("fraud proof without a conflict verified")
+	}
+
+	detector.Prune(4)
+	if frauds := observe(txB, testProof{"0", 3, 10, offender}); len(frauds) != 0 {
+		t.Fatal("pruned statement still reported")
+	}
+}
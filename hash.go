@@ -0,0 +1,54 @@
+package snapshot
+
+import (
+	"crypto"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"fmt"
+
+	_ "golang.org/x/crypto/sha3"
+)
+
+// IDs written to Snapshot.hash for each supported hash function
+const (
+	HashSHA256   = "sha256"
+	HashSHA384   = "sha384"
+	HashSHA512   = "sha512"
+	HashSHA3_256 = "sha3-256"
+)
+
+// Supported hash functions keyed by their snapshot ID
+var hashFuncs = map[string]crypto.Hash{
+	HashSHA256:   crypto.SHA256,
+	HashSHA384:   crypto.SHA384,
+	HashSHA512:   crypto.SHA512,
+	HashSHA3_256: crypto.SHA3_256,
+}
+
+/* HashID returns the ID recorded in a snapshot for the given hash
+function. An error is returned if the hash function is not supported */
+func HashID(hash crypto.Hash) (string, error) {
+	for id, h := range hashFuncs {
+		if h == hash {
+			return id, nil
+		}
+	}
+	return "", &HashErr{simpleErr{err: fmt.Errorf("%v", hash), msg: "Unsupported hash function"}}
+}
+
+/* HashFromID returns the hash function identified by a snapshot hash ID.
+Snapshots created before the hash field was written have an empty ID
+and were always hashed with SHA-256 */
+func HashFromID(id string) (crypto.Hash, error) {
+	if id == "" {
+		return crypto.SHA256, nil
+	}
+	hash, ok := hashFuncs[id]
+	if !ok {
+		return 0, &HashErr{simpleErr{err: fmt.Errorf("%q", id), msg: "Unknown hash ID"}}
+	}
+	if !hash.Available() {
+		return 0, &HashErr{simpleErr{err: fmt.Errorf("%q", id), msg: "Hash function not linked into binary"}}
+	}
+	return hash, nil
+}
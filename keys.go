@@ -0,0 +1,230 @@
+package snapshot
+
+import (
+	"container/list"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+/* KeyResolver looks up the public key a node used at a given epoch.
+Implementations may be slow or fail, and must return an
+UnknownSignerErr when they have no key for the node */
+type KeyResolver interface {
+	ResolveKey(ctx context.Context, id string, epoch int32) (crypto.PublicKey, error)
+}
+
+/* KeyRangeResolver is a KeyResolver whose keys change over time. Along
+with the key it returns the first and last epoch the key is used for so
+callers can reuse it for every epoch in that range */
+type KeyRangeResolver interface {
+	KeyResolver
+	ResolveKeyRange(ctx context.Context, id string, epoch int32) (pk crypto.PublicKey, first int32, last int32, err error)
+}
+
+/* KeyChangeNotifier is a KeyResolver whose keys can change after they
+were resolved. NotifyKeyChange registers forget to be called with the
+Node Id of every node whose keys changed. A CachingKeyResolver in front
+of it registers itself so it never serves a key that was replaced */
+type KeyChangeNotifier interface {
+	KeyResolver
+	NotifyKeyChange(forget func(id string))
+}
+
+/* MapKeyResolver is an in-memory KeyResolver holding one key per Node Id
+for every epoch */
+type MapKeyResolver map[string]crypto.PublicKey
+
+// ResolveKey returns the key stored for id
+func (mr MapKeyResolver) ResolveKey(ctx context.Context, id string, epoch int32) (crypto.PublicKey, error) {
+	pk := mr[id]
+	if missingKey(pk) {
+		return nil, unknownSignerErr(id)
+	}
+	return pk, nil
+}
+
+/* PEMDirKeyResolver is a KeyResolver that reads node keys from a
+directory holding one PEM file per node named <Node Id>.pem. Files may
+hold a PKIX "PUBLIC KEY" block or a PKCS #1 "RSA PUBLIC KEY" block */
+type PEMDirKeyResolver struct {
+	dir string
+}
+
+// NewPEMDirKeyResolver returns a PEMDirKeyResolver reading from dir
+func NewPEMDirKeyResolver(dir string) *PEMDirKeyResolver {
+	return &PEMDirKeyResolver{dir: dir}
+}
+
+// ResolveKey reads and parses the PEM file for id
+func (pr *PEMDirKeyResolver) ResolveKey(ctx context.Context, id string, epoch int32) (crypto.PublicKey, error) {
+	if id == "" || id == "." || id == ".." || filepath.Base(id) != id {
+		return nil, unknownSignerErr(id)
+	}
+	raw, err := os.ReadFile(filepath.Join(pr.dir, id+".pem"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, unknownSignerErr(id)
+	}
+	if err != nil {
+		return nil, &KeyErr{simpleErr{err: err, msg: "PEMDirKeyResolver.ResolveKey()"}}
+	}
+	return parsePEMPublicKey(raw)
+}
+
+// parsePEMPublicKey parses the first public key block of a PEM file
+func parsePEMPublicKey(raw []byte) (crypto.PublicKey, error) {
+	for {
+		var block *pem.Block
+		block, raw = pem.Decode(raw)
+		if block == nil {
+			return nil, &KeyErr{simpleErr{err: errors.New("no public key block"), msg: "parsePEMPublicKey()"}}
+		}
+
+		var pk crypto.PublicKey
+		var err error
+		switch block.Type {
+		case "PUBLIC KEY":
+			pk, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			pk, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, &KeyErr{simpleErr{err: err, msg: "parsePEMPublicKey()"}}
+		}
+		return pk, nil
+	}
+}
+
+/* CachingKeyResolver wraps a KeyResolver and keeps the keys of the most
+recently used nodes in memory. A key is reused for every epoch the
+wrapped resolver reports it for. Keys from a resolver that isn't a
+KeyRangeResolver are reused for every epoch, so resolvers whose keys
+change over time must implement it, and resolvers whose keys can change
+after they were resolved KeyChangeNotifier. Failed lookups are not
+cached. It is safe for concurrent use */
+type CachingKeyResolver struct {
+	resolver KeyResolver
+	size     int
+
+	mutex   sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+	// generation counts calls to Forget so lookups racing one aren't cached
+	generation uint64
+}
+
+// cacheEntry is the value stored in CachingKeyResolver.order
+type cacheEntry struct {
+	id    string
+	first int32
+	last  int32
+	pk    crypto.PublicKey
+}
+
+/* NewCachingKeyResolver returns a CachingKeyResolver holding at most size
+keys in front of resolver. If resolver is a KeyChangeNotifier the cache
+registers with it and lives as long as resolver does */
+func NewCachingKeyResolver(resolver KeyResolver, size int) *CachingKeyResolver {
+	cr := &CachingKeyResolver{
+		resolver: resolver,
+		size:     size,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+	if notifier, ok := resolver.(KeyChangeNotifier); ok {
+		notifier.NotifyKeyChange(cr.Forget)
+	}
+	return cr
+}
+
+/* ResolveKey returns the cached key for id if it is used at epoch or
+asks the wrapped resolver on a miss */
+func (cr *CachingKeyResolver) ResolveKey(ctx context.Context, id string, epoch int32) (crypto.PublicKey, error) {
+	cr.mutex.Lock()
+	if elem, ok := cr.entries[id]; ok {
+		if entry := elem.Value.(*cacheEntry); entry.first <= epoch && epoch <= entry.last {
+			cr.order.MoveToFront(elem)
+			cr.mutex.Unlock()
+			return entry.pk, nil
+		}
+	}
+	generation := cr.generation
+	cr.mutex.Unlock()
+
+	pk, first, last, err := resolveKeyRange(ctx, cr.resolver, id, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	if cr.size <= 0 || generation != cr.generation {
+		return pk, nil
+	}
+	entry := &cacheEntry{id: id, first: first, last: last, pk: pk}
+	if elem, ok := cr.entries[id]; ok {
+		elem.Value = entry
+		cr.order.MoveToFront(elem)
+		return pk, nil
+	}
+	cr.entries[id] = cr.order.PushFront(entry)
+	if cr.order.Len() > cr.size {
+		oldest := cr.order.Back()
+		cr.order.Remove(oldest)
+		delete(cr.entries, oldest.Value.(*cacheEntry).id)
+	}
+	return pk, nil
+}
+
+/* Forget drops the cached key of id. It is called by the wrapped
+resolver if that is a KeyChangeNotifier, otherwise call it when the key
+of id changes behind the cache */
+func (cr *CachingKeyResolver) Forget(id string) {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	cr.generation++
+	if elem, ok := cr.entries[id]; ok {
+		cr.order.Remove(elem)
+		delete(cr.entries, id)
+	}
+}
+
+/* resolveKeyRange asks resolver for a key and the epochs it is used
+for. Resolvers that aren't a KeyRangeResolver use a key for every epoch */
+func resolveKeyRange(ctx context.Context, resolver KeyResolver, id string, epoch int32) (crypto.PublicKey, int32, int32, error) {
+	if ranged, ok := resolver.(KeyRangeResolver); ok {
+		return ranged.ResolveKeyRange(ctx, id, epoch)
+	}
+	pk, err := resolver.ResolveKey(ctx, id, epoch)
+	return pk, math.MinInt32, math.MaxInt32, err
+}
+
+/* resolveKey asks resolver for a key and makes sure a missing key is
+always reported as an UnknownSignerErr */
+func resolveKey(ctx context.Context, resolver KeyResolver, id string, epoch int32) (crypto.PublicKey, error) {
+	if resolver == nil {
+		return nil, unknownSignerErr(id)
+	}
+	pk, err := resolver.ResolveKey(ctx, id, epoch)
+	if err != nil {
+		var unknown *UnknownSignerErr
+		if errors.As(err, &unknown) {
+			return nil, err
+		}
+		return nil, &KeyErr{simpleErr{err: err, msg: fmt.Sprintf("Resolving key for %q", id)}}
+	}
+	if missingKey(pk) {
+		return nil, unknownSignerErr(id)
+	}
+	return pk, nil
+}
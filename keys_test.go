@@ -0,0 +1,346 @@
+package snapshot
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// countingResolver counts lookups made against the wrapped resolver
+type countingResolver struct {
+	resolver KeyResolver
+	calls    int
+}
+
+func (cr *countingResolver) ResolveKey(ctx context.Context, id string, epoch int32) (crypto.PublicKey, error) {
+	cr.calls++
+	return cr.resolver.ResolveKey(ctx, id, epoch)
+}
+
+func writePEMKey(t *testing.T, dir string, id string, pk crypto.PublicKey) {
+	der, err := x509.MarshalPKIXPublicKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, id+".pem"), raw, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPEMDirKeyResolver(t *testing.T) {
+	dir := t.TempDir()
+	tx := createTransaction(1, 1, 10, "ID1", "ID2")
+	snapshot := NewSimpleSnapshot(tx)
+	for i := 0; i < 3; i++ {
+		id := strconv.Itoa(i)
+		writePEMKey(t, dir, id, &testKey(t, i).PublicKey)
+		tup, err := NewSimpleProofTuple(tx, id, 1, 5, testKey(t, i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot.AddProof(tup)
+	}
+
+	counter := &countingResolver{resolver: NewPEMDirKeyResolver(dir)}
+	sv := &SnapshotVerifier{Pass: 1, Keys: NewCachingKeyResolver(counter, 2), Verifier: pkcsVerifier}
+	for i := 0; i < 2; i++ {
+		if err := sv.Verify(snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Three keys through a cache of two evict each other in order
+	if counter.calls != 6 {
+		t.Fatalf("expected 6 lookups, got %d", counter.calls)
+	}
+
+	counter.calls = 0
+	sv.Keys = NewCachingKeyResolver(counter, 3)
+	sv.Verify(snapshot)
+	sv.Verify(snapshot)
+	if counter.calls != 3 {
+		t.Fatalf("expected 3 lookups with a warm cache, got %d", counter.calls)
+	}
+
+	// A node's key is looked up once no matter how many epochs it proves
+	counter.calls = 0
+	sv.Keys = NewCachingKeyResolver(counter, 3)
+	for epoch := int32(1); epoch <= 100; epoch++ {
+		next := NewSimpleSnapshot(tx)
+		tup, err := NewSimpleProofTuple(tx, "0", epoch, 5, testKey(t, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		next.AddProof(tup)
+		if err := sv.Verify(next); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if counter.calls != 1 {
+		t.Fatalf("expected 1 lookup across increasing epochs, got %d", counter.calls)
+	}
+
+	var unknown *UnknownSignerErr
+	for _, id := range []string{"missing", "../0", ""} {
+		if _, err := NewPEMDirKeyResolver(dir).ResolveKey(context.Background(), id, 1); !errors.As(err, &unknown) {
+			t.Fatalf("expected UnknownSignerErr for %q, got %v", id, err)
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey, newKey, otherKey := testKey(t, 0), testKey(t, 1), testKey(t, 2)
+	rr := NewRotatingKeyResolver(MapKeyResolver{"0": &oldKey.PublicKey}, pkcsVerifier)
+	ctx := context.Background()
+
+	forged, err := NewSimpleKeyRotation("0", 5, &otherKey.PublicKey, crypto.SHA256, otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rotationErr *RotationErr
+	if err := rr.AddRotation(ctx, forged); !errors.As(err, &rotationErr) {
+		t.Fatalf("expected RotationErr for a rotation signed by the wrong key, got %v", err)
+	}
+
+	rotation, err := NewSimpleKeyRotation("0", 5, &newKey.PublicKey, crypto.SHA256, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := rotation.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &SimpleKeyRotation{}
+	if err := decoded.Unmarshal(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := rr.AddRotation(ctx, decoded); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := NewSimpleKeyRotation("0", 5, &otherKey.PublicKey, crypto.SHA256, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rr.AddRotation(ctx, stale); !errors.As(err, &rotationErr) {
+		t.Fatalf("expected RotationErr for an out of order rotation, got %v", err)
+	}
+	if got := rr.Rotations("0"); len(got) != 1 {
+		t.Fatalf("expected 1 recorded rotation, got %d", len(got))
+	}
+
+	cases := []struct {
+		epoch  int32
+		signer crypto.Signer
+		valid  bool
+	}{
+		{4, oldKey, true},
+		{4, newKey, false},
+		{5, newKey, true},
+		{9, newKey, true},
+		{9, oldKey, false},
+	}
+	// A cache in front of the rotations must not reuse a key across them
+	for _, keys := range []KeyResolver{rr, NewCachingKeyResolver(rr, 4)} {
+		sv := &SnapshotVerifier{Pass: 1, Keys: keys, Verifier: pkcsVerifier}
+		for _, c := range cases {
+			tx := createTransaction(1, 1, 10, "ID1", "ID2")
+			snapshot := NewSimpleSnapshot(tx)
+			tup, err := NewSimpleProofTuple(tx, "0", c.epoch, 5, c.signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			snapshot.AddProof(tup)
+			if err := sv.Verify(snapshot); (err == nil) != c.valid {
+				t.Errorf("epoch %d: expected valid=%v, got %v", c.epoch, c.valid, err)
+			}
+		}
+	}
+
+	// A rotation added once the cache is warm replaces the cached key
+	sv := &SnapshotVerifier{Pass: 1, Keys: NewCachingKeyResolver(rr, 4), Verifier: pkcsVerifier}
+	tx := createTransaction(1, 1, 10, "ID1", "ID2")
+	if err := sv.Verify(createSnapshot(t, tx, testProof{"0", 9, 5, newKey})); err != nil {
+		t.Fatal(err)
+	}
+	next, err := NewSimpleKeyRotation("0", 10, &otherKey.PublicKey, crypto.SHA256, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rr.AddRotation(ctx, next); err != nil {
+		t.Fatal(err)
+	}
+	if err := sv.Verify(createSnapshot(t, tx, testProof{"0", 12, 5, newKey})); err == nil {
+		t.Fatal("cache kept serving a rotated away key")
+	}
+	if err := sv.Verify(createSnapshot(t, tx, testProof{"0", 12, 5, otherKey})); err != nil {
+		t.Fatalf("rotated key rejected through the cache: %v", err)
+	}
+}
+
+func TestRevocationList(t *testing.T) {
+	authority := testKey(t, 9)
+	list := NewSimpleRevocationList(2)
+	list.Revoke("1", 5, "key compromised")
+	if err := list.Sign(crypto.SHA256, authority); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := list.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &SimpleRevocationList{}
+	if err := decoded.Unmarshal(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.Verify(&authority.PublicKey, pkcsVerifier); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.GetVersion() != 2 || len(decoded.GetEntries()) != 1 {
+		t.Fatalf("unexpected list contents %d %v", decoded.GetVersion(), decoded.GetEntries())
+	}
+	decoded.Revoke("0", 1, "forged")
+	if err := decoded.Verify(&authority.PublicKey, pkcsVerifier); err == nil {
+		t.Fatal("a modified list still verified")
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for i := 0; i < 3; i++ {
+		keys[strconv.Itoa(i)] = &testKey(t, i).PublicKey
+	}
+	sv := &SnapshotVerifier{Pass: 1, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier, Revocations: list}
+	for _, epoch := range []int32{4, 5} {
+		tx := createTransaction(1, 1, 10, "ID1", "ID2")
+		snapshot := NewSimpleSnapshot(tx)
+		for i := 0; i < 3; i++ {
+			tup, err := NewSimpleProofTuple(tx, strconv.Itoa(i), epoch, 5, testKey(t, i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			snapshot.AddProof(tup)
+		}
+
+		report := sv.Report(snapshot)
+		if epoch < 5 {
+			if report.Err != nil || report.Revoked != 0 {
+				t.Fatalf("epoch %d: expected no revocations, got %v", epoch, report.Err)
+			}
+			continue
+		}
+		var revoked *RevokedSignerErr
+		result := report.Proofs[1]
+		if !result.Revoked || !errors.As(result.EpochErr, &revoked) || revoked.Entry.Epoch != 5 {
+			t.Fatalf("epoch %d: expected a revoked proof, got %+v", epoch, result)
+		}
+		if report.Revoked != 1 || report.Passed != 2 || report.Err == nil {
+			t.Fatalf("epoch %d: unexpected report %+v", epoch, report)
+		}
+	}
+
+	// Once node 1 reached epoch 4 its stolen key can't claim epoch 4 or earlier
+	tracker := NewEpochTracker()
+	honest := createSnapshot(t, createTransaction(1, 1, 10, "ID1", "ID2"), testProof{"1", 4, 5, testKey(t, 1)})
+	if err := tracker.Observe(honest, sv.Report(honest)); err != nil {
+		t.Fatal(err)
+	}
+	sv.Epochs = tracker
+	for _, epoch := range []int32{3, 4} {
+		stolen := createSnapshot(t, createTransaction(2, 1, 10, "ID1", "ID2"), testProof{"1", epoch, 5, testKey(t, 1)})
+		if report := sv.Report(stolen); report.Err == nil || !report.Proofs[0].Revoked {
+			t.Fatalf("stolen key claiming epoch %d was accepted: %+v", epoch, report.Proofs[0])
+		}
+	}
+	sv.Epochs = nil
+	if err := sv.Verify(honest); err != nil {
+		t.Fatalf("without epochs the claimed epoch should be trusted: %v", err)
+	}
+}
+
+func TestSelfCertifyingIDs(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := []crypto.Signer{edKey, ecKey, testKey(t, 0)}
+
+	id, err := Fingerprint(edKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	id512, err := FingerprintWithHash(edKey.Public(), crypto.SHA512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id) != 68 || id[:4] != "1220" || id512[:4] != "1340" {
+		t.Fatalf("unexpected fingerprints %s %s", id, id512)
+	}
+	var fpErr *FingerprintErr
+	for _, c := range []struct {
+		id    string
+		key   crypto.PublicKey
+		valid bool
+	}{
+		{id, edKey.Public(), true},
+		{id512, edKey.Public(), true},
+		{id, ecKey.Public(), false},
+		{"ID1", edKey.Public(), false},
+		{"1220", edKey.Public(), false},
+	} {
+		if err := CheckFingerprint(c.id, c.key); (err == nil) != c.valid || (err != nil && !errors.As(err, &fpErr)) {
+			t.Errorf("%q: expected valid=%v, got %v", c.id, c.valid, err)
+		}
+	}
+
+	tx := createTransaction(1, 1, 10, id, "ID2")
+	snapshot := NewSimpleSnapshot(tx)
+	for _, signer := range signers {
+		tup, err := NewSelfCertifyingProofTuple(tx, 1, 5, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot.AddProof(tup)
+	}
+	sv := &SnapshotVerifier{Pass: 1, SelfCertifying: true}
+	report := sv.Report(snapshot)
+	if report.Err != nil {
+		t.Fatal(report.Err)
+	}
+	if report.Proofs[0].Role != GainerRole {
+		t.Fatalf("fingerprint Id was not matched to the gainer, got %v", report.Proofs[0].Role)
+	}
+
+	// Embedded keys aren't trusted outside of self-certifying mode
+	var unknown *UnknownSignerErr
+	sv.SelfCertifying = false
+	report = sv.Report(snapshot)
+	if report.Err == nil || !errors.As(report.Proofs[0].TransactionErr, &unknown) {
+		t.Fatalf("expected UnknownSignerErr, got %v", report.Proofs[0].TransactionErr)
+	}
+
+	// A node can't claim another node's Id with its own key
+	forged, err := NewSimpleProofTuple(tx, id, 1, 5, ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.protoProofTuple.PublicKey = snapshot.GetProofs()[1].protoProofTuple.PublicKey
+	snapshot = NewSimpleSnapshot(tx)
+	snapshot.AddProof(forged)
+	sv.SelfCertifying = true
+	report = sv.Report(snapshot)
+	if report.Proofs[0].KeyFound || !errors.As(report.Proofs[0].TransactionErr, &fpErr) {
+		t.Fatalf("expected FingerprintErr, got %+v", report.Proofs[0])
+	}
+}
@@ -0,0 +1,121 @@
+package snapshot
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+/* BalanceDiscrepancy describes a proof whose attested balance disagrees
+with the balance a Ledger computed for the node */
+type BalanceDiscrepancy struct {
+	NodeId   string
+	Epoch    int32
+	Attested float64
+	Computed float64
+}
+
+/* Ledger keeps the balance of every node by applying the transactions
+of verified snapshots. The gainer receives the exchange, the loser pays
+it and every bystander receives the reward. Nodes start with a zero
+balance unless SetBalance gives them one. Snapshots must be applied in
+the order they were made, the ledger follows the epochs of every node
+like an EpochTracker to catch repeated, skipped and reordered
+snapshots. It is safe for concurrent use */
+type Ledger struct {
+	tolerance float64
+	epochs    *EpochTracker
+
+	mutex    sync.Mutex
+	balances map[string]float64
+}
+
+/* NewLedger returns an empty Ledger. Attested balances within tolerance
+of the computed balance are accepted */
+func NewLedger(tolerance float64) *Ledger {
+	return &Ledger{
+		tolerance: tolerance,
+		epochs:    NewEpochTracker(),
+		balances:  make(map[string]float64),
+	}
+}
+
+// SetBalance sets the balance of node id, for example to an opening balance
+func (l *Ledger) SetBalance(id string, balance float64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.balances[id] = balance
+}
+
+// Balance returns the current balance of node id
+func (l *Ledger) Balance(id string) float64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.balances[id]
+}
+
+/* Epoch returns the last epoch node id proved in an applied snapshot
+and whether it proved any */
+func (l *Ledger) Epoch(id string) (int32, bool) {
+	return l.epochs.Epoch(id)
+}
+
+/* Apply applies the transaction of a verified snapshot and compares the
+balance attested by every valid proof, as marked in report, with the
+node's balance after the transaction. Disagreeing proofs are returned,
+the transaction is applied either way. If the snapshot failed
+verification its error is returned and if report belongs to another
+snapshot a ReportErr. A LedgerErr is returned if the transaction's
+exchange or reward is negative or not finite and an EpochErr if a valid
+proof's epoch doesn't follow the last epoch its node proved. Nothing is
+applied when an error is returned */
+func (l *Ledger) Apply(snapshot *SimpleSnapshot, report *VerificationReport) ([]BalanceDiscrepancy, error) {
+	proven, err := report.validEpochs(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	tx := snapshot.GetTransaction()
+	exchange, reward := tx.GetValueExchange(), tx.GetBystanderReward()
+	for _, amount := range []float64{exchange, reward} {
+		if math.IsNaN(amount) || math.IsInf(amount, 0) || amount < 0 {
+			return nil, &LedgerErr{simpleErr{err: fmt.Errorf("amount %v", amount), msg: "Invalid transaction amount"}}
+		}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if err := l.epochs.advance(proven); err != nil {
+		return nil, err
+	}
+
+	if gainer := tx.GetGainingParty(); gainer != "" {
+		l.balances[gainer] += exchange
+	}
+	if loser := tx.GetLosingParty(); loser != "" {
+		l.balances[loser] -= exchange
+	}
+	for _, bystander := range tx.GetBystanders() {
+		l.balances[bystander] += reward
+	}
+
+	var discrepancies []BalanceDiscrepancy
+	for _, epoch := range proven {
+		if l.disagrees(epoch) {
+			discrepancies = append(discrepancies, BalanceDiscrepancy{
+				NodeId:   epoch.GetId(),
+				Epoch:    epoch.GetEpochNumber(),
+				Attested: epoch.GetBalance(),
+				Computed: l.balances[epoch.GetId()],
+			})
+		}
+	}
+	return discrepancies, nil
+}
+
+/* disagrees returns whether the balance attested by an epoch is further
+than the tolerance from the computed balance of its node. Non-finite
+balances always disagree */
+func (l *Ledger) disagrees(epoch *SimpleEpochTriplet) bool {
+	diff := math.Abs(epoch.GetBalance() - l.balances[epoch.GetId()])
+	return !(diff <= l.tolerance)
+}
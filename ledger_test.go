@@ -0,0 +1,101 @@
+package snapshot
+
+import (
+	"crypto"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestLedger(t *testing.T) {
+	ledger := NewLedger(1e-9)
+	ledger.SetBalance("ID2", 100)
+
+	signers := map[string]crypto.Signer{"ID1": testKey(t, 0), "ID2": testKey(t, 1), "b1": testKey(t, 2), "b2": testKey(t, 3)}
+	keys := MapKeyResolver{}
+	for id, signer := range signers {
+		keys[id] = signer.Public()
+	}
+	sv := &SnapshotVerifier{Pass: 0.5, Keys: keys, Verifier: pkcsVerifier}
+
+	tx := createTransaction(1, 0.5, 10, "ID1", "ID2")
+	tx.SetBystanders([]string{"b1", "b2"})
+	snapshot := createSnapshot(t, tx,
+		testProof{"ID1", 1, 10, signers["ID1"]}, testProof{"ID2", 1, 90, signers["ID2"]},
+		testProof{"b1", 1, 0.5, signers["b1"]}, testProof{"b2", 1, 7, signers["b2"]})
+	// A forged proof attesting a wrong balance isn't compared
+	forged := createSnapshot(t, tx, testProof{"b1", 1, 1e6, signers["ID1"]})
+	snapshot.protoSnapshot.Proofs = append(snapshot.protoSnapshot.Proofs, forged.protoSnapshot.Proofs...)
+
+	discrepancies, err := ledger.Apply(snapshot, sv.Report(snapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discrepancies) != 1 {
+		t.Fatalf("expected 1 discrepancy, got %+v", discrepancies)
+	}
+	if d := discrepancies[0]; d.NodeId != "b2" || d.Attested != 7 || d.Computed != 0.5 || d.Epoch != 1 {
+		t.Fatalf("unexpected discrepancy %+v", d)
+	}
+	for id, expected := range map[string]float64{"ID1": 10, "ID2": 90, "b1": 0.5, "b2": 0.5} {
+		if balance := ledger.Balance(id); balance != expected {
+			t.Errorf("%s: balance %v, expected %v", id, balance, expected)
+		}
+	}
+
+	// Repeated, skipped and reordered snapshots are rejected without being applied
+	var epochErr *EpochErr
+	if _, err := ledger.Apply(snapshot, sv.Report(snapshot)); !errors.As(err, &epochErr) {
+		t.Fatalf("expected EpochErr for a repeated snapshot, got %v", err)
+	}
+	next := createTransaction(2, 0, 5, "ID2", "ID1")
+	skipped := createSnapshot(t, next, testProof{"ID1", 3, 5, signers["ID1"]}, testProof{"ID2", 3, 95, signers["ID2"]})
+	if _, err := ledger.Apply(skipped, sv.Report(skipped)); !errors.As(err, &epochErr) {
+		t.Fatalf("expected EpochErr for a skipped epoch, got %v", err)
+	}
+	second := createSnapshot(t, next, testProof{"ID1", 2, 5, signers["ID1"]}, testProof{"ID2", 2, 95, signers["ID2"]})
+	if _, err := ledger.Apply(second, sv.Report(second)); err != nil {
+		t.Fatal(err)
+	}
+	reordered := createSnapshot(t, createTransaction(3, 0, 5, "ID2", "ID1"),
+		testProof{"ID1", 1, 0, signers["ID1"]}, testProof{"ID2", 1, 100, signers["ID2"]})
+	if _, err := ledger.Apply(reordered, sv.Report(reordered)); !errors.As(err, &epochErr) {
+		t.Fatalf("expected EpochErr for an out of order snapshot, got %v", err)
+	}
+	if balance := ledger.Balance("ID1"); balance != 5 {
+		t.Fatalf("rejected snapshots changed the balance to %v", balance)
+	}
+	if epoch, _ := ledger.Epoch("ID1"); epoch != 2 {
+		t.Fatalf("expected ID1 at epoch 2, got %d", epoch)
+	}
+
+	// A report of another snapshot is rejected
+	var reportErr *ReportErr
+	mismatched := createSnapshot(t, next, testProof{"ID1", 3, 1e6, signers["ID2"]})
+	if _, err := ledger.Apply(mismatched, sv.Report(second)); !errors.As(err, &reportErr) {
+		t.Fatalf("expected ReportErr for a mismatched report, got %v", err)
+	}
+
+	unverified := createSnapshot(t, next, testProof{"ID1", 3, 0, signers["ID1"]})
+	unverified.protoSnapshot.Proofs[0].TransactionSign = nil
+	if _, err := ledger.Apply(unverified, sv.Report(unverified)); err == nil {
+		t.Fatal("snapshot that failed verification was applied")
+	}
+
+	var ledgerErr *LedgerErr
+	next.SetValueExchange(-1)
+	negative := createSnapshot(t, next, testProof{"ID1", 3, 5, signers["ID1"]})
+	if _, err := ledger.Apply(negative, sv.Report(negative)); !errors.As(err, &ledgerErr) {
+		t.Errorf("expected LedgerErr for a negative exchange, got %v", err)
+	}
+	// Non-finite amounts can't be signed, check them on their own
+	for _, exchange := range []float64{math.NaN(), math.Inf(1)} {
+		next.SetValueExchange(exchange)
+		if _, err := ledger.Apply(NewSimpleSnapshot(next), &VerificationReport{}); !errors.As(err, &ledgerErr) {
+			t.Errorf("exchange %v: expected LedgerErr, got %v", exchange, err)
+		}
+	}
+	if balance := ledger.Balance("ID1"); balance != 5 {
+		t.Fatalf("rejected transaction changed the balance to %v", balance)
+	}
+}
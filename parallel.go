@@ -0,0 +1,107 @@
+package snapshot
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+/* VerifyContext returns nil if enough of the SimpleProofTuples held by
+the snapshot are valid to meet the Pass requirement. Proofs are checked
+concurrently and checking stops as soon as the outcome is known or ctx
+is done. The result is the same as Verify's */
+func (sv *SnapshotVerifier) VerifyContext(ctx context.Context, snapshot *SimpleSnapshot) error {
+	return sv.ReportContext(ctx, snapshot).Err
+}
+
+/* ReportContext verifies the snapshot like VerifyContext and returns the
+outcome of every proof that was checked. Proofs left unchecked once the
+outcome was known are marked as Skipped. If ctx is done before the
+outcome is known the report's Err is the context's error */
+func (sv *SnapshotVerifier) ReportContext(ctx context.Context, snapshot *SimpleSnapshot) *VerificationReport {
+	sc, err := sv.prepare(snapshot)
+	if err != nil {
+		return &VerificationReport{Err: err}
+	}
+
+	proofs := snapshot.GetProofs()
+	results := sv.classify(snapshot.GetTransaction(), proofs)
+	checked := make([]bool, len(proofs))
+	// The tally reads results, so it must be built before any worker writes them
+	t := newTally(sv.Pass, sv.Policy, attestsWeight(sv.Weight), snapshot.GetTransaction(), results)
+
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan int)
+	done := make(chan int, len(proofs))
+
+	var wg sync.WaitGroup
+	for w := 0; w < sv.workers(len(proofs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if workCtx.Err() != nil {
+					return
+				}
+				sv.checkProof(workCtx, proofs[i], sc, &results[i])
+				done <- i
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range proofs {
+			select {
+			case jobs <- i:
+			case <-workCtx.Done():
+				return
+			}
+		}
+	}()
+
+	var ctxErr error
+	for !t.decided() {
+		select {
+		case i := <-done:
+			checked[i] = true
+			t.add(results[i])
+		case <-ctx.Done():
+			ctxErr = ctx.Err()
+		}
+		if ctxErr != nil {
+			break
+		}
+	}
+	cancel()
+	wg.Wait()
+
+	for i := range proofs {
+		if !checked[i] && results[i].counted() {
+			results[i] = ProofResult{
+				NodeId:  results[i].NodeId,
+				Role:    results[i].Role,
+				Weight:  results[i].Weight,
+				Skipped: true,
+			}
+		}
+	}
+	report := newReport(proofs, results, t)
+	if ctxErr != nil {
+		report.Err = ctxErr
+	}
+	return sv.accept(ctx, snapshot, report)
+}
+
+/* workers returns the number of goroutines used to check n proofs.
+It defaults to GOMAXPROCS when Workers isn't set */
+func (sv *SnapshotVerifier) workers(n int) int {
+	workers := sv.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	return workers
+}
@@ -0,0 +1,86 @@
+package snapshot
+
+import (
+	"fmt"
+)
+
+// ProofRole is the part a proving node played in a transaction
+type ProofRole int
+
+const (
+	// NonParticipant nodes are not named by the transaction
+	NonParticipant ProofRole = iota
+	// GainerRole is the node returned by GetGainingParty()
+	GainerRole
+	// LoserRole is the node returned by GetLosingParty()
+	LoserRole
+	// BystanderRole nodes are returned by GetBystanders()
+	BystanderRole
+)
+
+// String returns a readable name for the role
+func (pr ProofRole) String() string {
+	switch pr {
+	case GainerRole:
+		return "gainer"
+	case LoserRole:
+		return "loser"
+	case BystanderRole:
+		return "bystander"
+	}
+	return "non-participant"
+}
+
+/* NonParticipantMode decides how proofs from nodes that are not named by
+the transaction are treated */
+type NonParticipantMode int
+
+const (
+	// CountNonParticipants counts their proofs like any other proof
+	CountNonParticipants NonParticipantMode = iota
+	// IgnoreNonParticipants leaves their proofs out of verification
+	IgnoreNonParticipants
+	// RejectNonParticipants fails any snapshot holding one of their proofs
+	RejectNonParticipants
+)
+
+/* VerificationPolicy describes which proofs a snapshot must hold on top
+of the SnapshotVerifier's Pass requirement. Set Pass to 0 to rely on
+the policy alone */
+type VerificationPolicy struct {
+	// RequireGainer fails snapshots without a valid gaining party proof
+	RequireGainer bool
+	// RequireLoser fails snapshots without a valid losing party proof
+	RequireLoser bool
+	/* BystanderQuorum is the fraction of the transaction's bystanders
+	that must provide a valid proof. Missing proofs count against it */
+	BystanderQuorum float64
+	// NonParticipants decides how proofs from other nodes are treated
+	NonParticipants NonParticipantMode
+}
+
+/* roleOf returns the role the node with the given Id played in the
+transaction. A node named more than once takes its first role in the
+order gainer, loser, bystander */
+func roleOf(tx *SimpleTransaction, id string) ProofRole {
+	if id == "" {
+		return NonParticipant
+	}
+	switch id {
+	case tx.GetGainingParty():
+		return GainerRole
+	case tx.GetLosingParty():
+		return LoserRole
+	}
+	for _, bystander := range tx.GetBystanders() {
+		if id == bystander {
+			return BystanderRole
+		}
+	}
+	return NonParticipant
+}
+
+// policyErr builds the error returned when a policy requirement fails
+func policyErr(format string, args ...interface{}) error {
+	return &PolicyErr{simpleErr{err: fmt.Errorf(format, args...), msg: "Verification policy not met"}}
+}
@@ -1,16 +1,38 @@
 package snapshot
 
 import (
+	"bytes"
 	"crypto"
-	"crypto/rand"
 	_ "crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
+	"fmt"
 
 	"google.golang.org/protobuf/proto"
 )
 
-/* ProofHashFunc stores the crypto.Hash that is used for transaction
-proofing and verification */
+/* Domain separation labels mixed into every digest so a digest made for
+one kind of payload can never be reused as another */
+const (
+	TransactionDomain   = "hivenet/tx/v1"
+	EpochDomain         = "hivenet/epoch/v1"
+	RotationDomain      = "hivenet/rotation/v1"
+	RevocationDomain    = "hivenet/revocation/v1"
+	TransactionIdDomain = "hivenet/txid/v1"
+	SnapshotDomain      = "hivenet/snapshot/v1"
+)
+
+/* Encoding versions of the signature fields of a SimpleProofTuple.
+Proofs of ProofVersionBase64 hold base64 text and are still read, new
+proofs are always created with ProofVersionRaw */
+const (
+	ProofVersionBase64 uint32 = 0
+	ProofVersionRaw    uint32 = 1
+)
+
+/* ProofHashFunc stores the crypto.Hash that new snapshots and proofs
+are created with when no hash is given. Verification always uses the
+hash recorded in the snapshot being verified */
 var ProofHashFunc crypto.Hash = crypto.SHA256
 
 // Interface for any datatype that can be Marshaled
@@ -24,65 +46,303 @@ type SimpleProofTuple struct {
 }
 
 /* NewSimpleProofTuple instantiates a new SimpleProofTuple with
-the given attributes */
+the given attributes using ProofHashFunc */
 func NewSimpleProofTuple(tx *SimpleTransaction, id string, epoch int32, balance float64, signer crypto.Signer) (*SimpleProofTuple, error) {
-	tHashed, err := digestMarshaler(tx)
+	return NewSimpleProofTupleWithHash(tx, id, epoch, balance, ProofHashFunc, signer)
+}
+
+/* NewSelfCertifyingProofTuple instantiates a new SimpleProofTuple whose
+Node Id is the Fingerprint of the signer's public key. The key is
+embedded in the proof so it can be verified without knowing the node */
+func NewSelfCertifyingProofTuple(tx *SimpleTransaction, epoch int32, balance float64, signer crypto.Signer) (*SimpleProofTuple, error) {
+	pk := signer.Public()
+	id, err := Fingerprint(pk)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(pk)
+	if err != nil {
+		return nil, &KeyErr{simpleErr{err: err, msg: "NewSelfCertifyingProofTuple()"}}
+	}
+	proof, err := NewSimpleProofTuple(tx, id, epoch, balance, signer)
+	if err != nil {
+		return nil, err
+	}
+	proof.protoProofTuple.PublicKey = der
+	return proof, nil
+}
+
+/* NewSimpleProofTupleWithHash instantiates a new SimpleProofTuple with
+the given attributes. The proof records the hash and the signature
+scheme of the signer so it can be verified independently of the other
+proofs in its snapshot */
+func NewSimpleProofTupleWithHash(tx *SimpleTransaction, id string, epoch int32, balance float64,
+	hash crypto.Hash, signer crypto.Signer) (*SimpleProofTuple, error) {
+	hashID, err := HashID(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	tHashed, err := transactionDigest(tx, hash)
 	if err != nil {
 		return nil, &DigestErr{simpleErr{err: err, msg: "NewSimpleProofTuple() on Transaction"}}
 	}
-	transactionSign, err := signer.Sign(rand.Reader, tHashed, ProofHashFunc)
+	transactionSign, err := signDigest(signer, tHashed, hash)
 	if err != nil {
 		return nil, &SignatureErr{simpleErr{err: err, msg: "NewSimpleProofTuple() on Transaction"}}
 	}
 
 	EpochTriplet := NewSimpleEpochTriplet(id, epoch, balance)
-	eHashed, err := digestMarshaler(EpochTriplet)
+	eHashed, err := epochDigest(EpochTriplet, hash, tx.GetNetwork(), tHashed)
 	if err != nil {
 		return nil, &DigestErr{simpleErr{err: err, msg: "NewSimpleProofTuple() on Epoch"}}
 	}
-	epochSign, err := signer.Sign(rand.Reader, eHashed, ProofHashFunc)
+	epochSign, err := signDigest(signer, eHashed, hash)
 	if err != nil {
 		return nil, &SignatureErr{simpleErr{err: err, msg: "NewSimpleProofTuple() on Epoch"}}
 	}
 
-	b64TransactionSign := base64.StdEncoding.EncodeToString(transactionSign)
-	b64EpochSign := base64.StdEncoding.EncodeToString(epochSign)
 	return &SimpleProofTuple{
 		protoProofTuple: &Snapshot_ProofTuple{
 			Epoch:           EpochTriplet.protoEpochTriplet,
-			TransactionSign: b64TransactionSign,
-			EpochSign:       b64EpochSign,
+			TransactionSign: transactionSign,
+			EpochSign:       epochSign,
+			Scheme:          schemeOf(signer),
+			Hash:            hashID,
+			Version:         ProofVersionRaw,
 		},
 	}, nil
 }
 
-/* digestMarshaler hashes the marshaler with the ProofHashFunc and
-slices the digest down to the size specified by the hash function.
-Cutting the digest down shouldn't have to be done but for some reason
-the crypto.Hash SHA256 function returns 42 bytes instead of 32 like
-using the crypto/256 library directly does */
-func digestMarshaler(m marshaler) ([]byte, error) {
+/* digestMarshaler encodes m with the canonical signing encoding and
+hashes it, prefixed by the domain label, with the given hash function.
+The label is written as a canonical string value (4 byte big-endian
+length followed by its bytes). This is the digest that every signature
+in the module is created over */
+func digestMarshaler(m canonicalMarshaler, domain string, hash crypto.Hash) ([]byte, error) {
+	serial, err := m.MarshalCanonical()
+	if err != nil {
+		return nil, &MarshalErr{simpleErr{err: err, msg: "digestMarshaler()"}}
+	}
+
+	ce := &canonicalEncoder{}
+	ce.putStringValue(domain)
+	label, err := ce.bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := hash.New()
+	hasher.Write(label)
+	hasher.Write(serial)
+	return hasher.Sum(nil), nil
+}
+
+/* transactionDigest returns the digest a node signs to prove a
+transaction */
+func transactionDigest(tx *SimpleTransaction, hash crypto.Hash) ([]byte, error) {
+	return digestMarshaler(tx, TransactionDomain, hash)
+}
+
+/* legacyDigestMarshaler reproduces the digest that older releases signed.
+Those releases called hasher.Sum(serial), which appends the hash of an
+empty input to the serialized data instead of hashing it, and then cut
+the result down to the hash size. The resulting "digest" is mostly a
+prefix of the serialized data, so it must never be used for signing.
+It only exists so snapshots signed the old way can still be recognized */
+func legacyDigestMarshaler(m marshaler, hash crypto.Hash) ([]byte, error) {
 	serial, err := m.Marshal()
 	if err != nil {
-		return nil, &MarshalErr{simpleErr{err: err, msg: "NewSimpleProofTuple()"}}
+		return nil, &MarshalErr{simpleErr{err: err, msg: "legacyDigestMarshaler()"}}
 	}
 
-	hasher := ProofHashFunc.New()
-	hasher.Reset()
-	tHashed := hasher.Sum(serial)
-	return tHashed[:hasher.Size()], nil
+	hasher := hash.New()
+	legacy := hasher.Sum(serial)
+	return legacy[:hasher.Size()], nil
 }
 
 /* GetTransactionSignature returns the signature of a transaction
-created using a nodes private key */
-func (sp *SimpleProofTuple) GetTransactionSignature() string {
-	return sp.protoProofTuple.TransactionSign
+created using a nodes private key. A MalformedSignatureErr is returned
+if the stored signature can't be decoded */
+func (sp *SimpleProofTuple) GetTransactionSignature() ([]byte, error) {
+	return decodeSignature(sp.protoProofTuple.GetTransactionSign(), sp.GetVersion())
 }
 
 /* GetEpochSignature returns the signature of an epoch created using
-a nodes private key */
-func (sp *SimpleProofTuple) GetEpochSignature() string {
-	return sp.protoProofTuple.EpochSign
+a nodes private key. A MalformedSignatureErr is returned if the stored
+signature can't be decoded */
+func (sp *SimpleProofTuple) GetEpochSignature() ([]byte, error) {
+	return decodeSignature(sp.protoProofTuple.GetEpochSign(), sp.GetVersion())
+}
+
+// GetVersion returns the encoding version of the proof's signatures
+func (sp *SimpleProofTuple) GetVersion() uint32 {
+	return sp.protoProofTuple.GetVersion()
+}
+
+/* Upgrade rewrites the signatures of an older proof with the current
+encoding. The signatures themselves don't change so the proof still
+verifies */
+func (sp *SimpleProofTuple) Upgrade() error {
+	if sp.GetVersion() == ProofVersionRaw {
+		return nil
+	}
+	tSig, err := sp.GetTransactionSignature()
+	if err != nil {
+		return err
+	}
+	eSig, err := sp.GetEpochSignature()
+	if err != nil {
+		return err
+	}
+	sp.protoProofTuple.TransactionSign = tSig
+	sp.protoProofTuple.EpochSign = eSig
+	sp.protoProofTuple.Version = ProofVersionRaw
+	return nil
+}
+
+/* decodeSignature returns the raw signature stored in a signature field
+of the given encoding version */
+func decodeSignature(stored []byte, version uint32) ([]byte, error) {
+	var sig []byte
+	switch version {
+	case ProofVersionBase64:
+		sig = make([]byte, base64.StdEncoding.DecodedLen(len(stored)))
+		n, err := base64.StdEncoding.Decode(sig, stored)
+		if err != nil {
+			return nil, &MalformedSignatureErr{simpleErr{err: err, msg: "Invalid base64 signature"}}
+		}
+		sig = sig[:n]
+	case ProofVersionRaw:
+		sig = stored
+	default:
+		return nil, &MalformedSignatureErr{simpleErr{err: fmt.Errorf("version %d", version),
+			msg: "Unsupported signature encoding"}}
+	}
+	if len(sig) == 0 {
+		return nil, &MalformedSignatureErr{simpleErr{err: nil, msg: "Empty signature"}}
+	}
+	return sig, nil
+}
+
+/* GetScheme returns the signature scheme the proof was signed with. It
+is empty for proofs that don't record one */
+func (sp *SimpleProofTuple) GetScheme() string {
+	return sp.protoProofTuple.Scheme
+}
+
+/* GetHashID returns the ID of the hash function the proof was signed
+with. It is empty for proofs that use the hash of their snapshot */
+func (sp *SimpleProofTuple) GetHashID() string {
+	return sp.protoProofTuple.Hash
+}
+
+/* GetPublicKey returns the public key embedded in the proof, or nil if
+there is none. The key can only be trusted once the Node Id is checked
+to be its fingerprint */
+func (sp *SimpleProofTuple) GetPublicKey() (crypto.PublicKey, error) {
+	der := sp.protoProofTuple.GetPublicKey()
+	if len(der) == 0 {
+		return nil, nil
+	}
+	pk, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, &KeyErr{simpleErr{err: err, msg: "SimpleProofTuple.GetPublicKey()"}}
+	}
+	return pk, nil
+}
+
+/* SetCertificates attaches the signer's X.509 certificate chain, leaf
+first, to the proof */
+func (sp *SimpleProofTuple) SetCertificates(chain []*x509.Certificate) {
+	raw := make([][]byte, 0, len(chain))
+	for _, cert := range chain {
+		raw = append(raw, cert.Raw)
+	}
+	sp.protoProofTuple.Certificates = raw
+}
+
+// GetCertificates parses and returns the certificate chain of the proof
+func (sp *SimpleProofTuple) GetCertificates() ([]*x509.Certificate, error) {
+	chain := make([]*x509.Certificate, 0, len(sp.protoProofTuple.GetCertificates()))
+	for _, raw := range sp.protoProofTuple.GetCertificates() {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, &KeyErr{simpleErr{err: err, msg: "SimpleProofTuple.GetCertificates()"}}
+		}
+		chain = append(chain, cert)
+	}
+	return chain, nil
+}
+
+/* SetCertificateRef makes the proof refer to the signer's certificate
+chain instead of carrying it */
+func (sp *SimpleProofTuple) SetCertificateRef(ref string) {
+	sp.protoProofTuple.CertificateRef = ref
+}
+
+// GetCertificateRef returns the certificate reference of the proof
+func (sp *SimpleProofTuple) GetCertificateRef() string {
+	return sp.protoProofTuple.GetCertificateRef()
+}
+
+/* MarshalCanonical serializes SimpleProofTuple with the canonical
+signing encoding. Signatures are written as raw bytes whatever version
+the proof is stored with, so the version field itself is left out */
+func (sp *SimpleProofTuple) MarshalCanonical() ([]byte, error) {
+	proof := sp.protoProofTuple
+	tSig, err := sp.GetTransactionSignature()
+	if err != nil {
+		return nil, err
+	}
+	eSig, err := sp.GetEpochSignature()
+	if err != nil {
+		return nil, err
+	}
+
+	ce := &canonicalEncoder{}
+	ce.putMessage(1, &SimpleEpochTriplet{protoEpochTriplet: proof.GetEpoch()})
+	ce.putBytes(2, tSig)
+	ce.putBytes(3, eSig)
+	ce.putString(4, proof.GetScheme())
+	ce.putString(5, proof.GetHash())
+	ce.putBytes(7, proof.GetPublicKey())
+	ce.putBytesList(8, proof.GetCertificates())
+	ce.putString(9, proof.GetCertificateRef())
+	return ce.bytes()
+}
+
+/* signedStatement returns the canonical encoding of the parts of the
+proof its signatures vouch for, the epoch and the signatures themselves */
+func (sp *SimpleProofTuple) signedStatement() ([]byte, error) {
+	tSig, err := sp.GetTransactionSignature()
+	if err != nil {
+		return nil, err
+	}
+	eSig, err := sp.GetEpochSignature()
+	if err != nil {
+		return nil, err
+	}
+
+	ce := &canonicalEncoder{}
+	ce.putMessage(1, sp.GetEpoch())
+	ce.putBytes(2, tSig)
+	ce.putBytes(3, eSig)
+	return ce.bytes()
+}
+
+/* Equal returns whether both SimpleProofTuples hold the same epoch and
+signatures, even if they are stored with different encoding versions.
+Unsigned details such as an embedded key or certificate chain are
+ignored, so they can't be swapped to make a copy look like a different
+proof */
+func (sp *SimpleProofTuple) Equal(other *SimpleProofTuple) bool {
+	a, aErr := sp.signedStatement()
+	b, bErr := other.signedStatement()
+	if aErr != nil || bErr != nil {
+		return proto.Equal(sp.protoProofTuple, other.protoProofTuple)
+	}
+	return bytes.Equal(a, b)
 }
 
 // GetEpoch returns the SimpleEpochTriplet embedded in SimpleProofTuple
@@ -110,13 +370,18 @@ func NewSimpleEpochTriplet(id string, epoch int32, balance float64) *SimpleEpoch
 	}
 }
 
-// Sign signs the epoch using the passed in signer object
-func (se *SimpleEpochTriplet) Sign(signer crypto.Signer) ([]byte, error) {
-	digest, err := digestMarshaler(se)
+/* Sign signs the epoch using the passed in signer object. The signature
+is bound to the transaction and hash function of the given snapshot */
+func (se *SimpleEpochTriplet) Sign(snapshot *SimpleSnapshot, signer crypto.Signer) ([]byte, error) {
+	hash, err := snapshot.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	digest, err := snapshotEpochDigest(se, snapshot, hash)
 	if err != nil {
 		return nil, &DigestErr{simpleErr{err: err, msg: "SimpleEpochTriplet.Sign()"}}
 	}
-	sig, err := signer.Sign(rand.Reader, digest, ProofHashFunc)
+	sig, err := signDigest(signer, digest, hash)
 	if err != nil {
 		return nil, &SignatureErr{simpleErr{err: err, msg: "SimpleEpochTriplet.Sign()"}}
 	}
@@ -124,13 +389,20 @@ func (se *SimpleEpochTriplet) Sign(signer crypto.Signer) ([]byte, error) {
 }
 
 /* Verify checks to see if the provided signature was signed by
-the given public key */
-func (se *SimpleEpochTriplet) Verify(pk crypto.PublicKey, sig []byte, verf Verifier) error {
-	digest, err := digestMarshaler(se)
+the given public key for the transaction held by snapshot */
+func (se *SimpleEpochTriplet) Verify(snapshot *SimpleSnapshot, pk crypto.PublicKey, sig []byte, verf Verifier) error {
+	if missingKey(pk) {
+		return unknownSignerErr(se.GetId())
+	}
+	hash, err := snapshot.GetHash()
+	if err != nil {
+		return err
+	}
+	digest, err := snapshotEpochDigest(se, snapshot, hash)
 	if err != nil {
 		return &DigestErr{simpleErr{err: err, msg: "SimpleEpochTriplet.Verify()"}}
 	}
-	return verf(pk, ProofHashFunc, digest, sig)
+	return verf(pk, hash, digest, sig)
 }
 
 // Marshal serializes SimpleEpochTriplet into a slice of bytes
@@ -142,6 +414,18 @@ func (se *SimpleEpochTriplet) Marshal() ([]byte, error) {
 	return out, nil
 }
 
+/* MarshalCanonical serializes SimpleEpochTriplet with the canonical
+signing encoding. This is the encoding that epoch digests are computed
+over */
+func (se *SimpleEpochTriplet) MarshalCanonical() ([]byte, error) {
+	triplet := se.protoEpochTriplet
+	ce := &canonicalEncoder{}
+	ce.putString(1, triplet.GetId())
+	ce.putInt32(2, triplet.GetEpoch())
+	ce.putDouble(3, triplet.GetBalance())
+	return ce.bytes()
+}
+
 // Unmarshal deserializes SimpleEpochTriplet from a slice of bytes
 func (se *SimpleEpochTriplet) Unmarshal(serial []byte) error {
 	se.protoEpochTriplet = &Snapshot_ProofTuple_EpochTriplet{}
@@ -165,3 +449,48 @@ func (se *SimpleEpochTriplet) GetEpochNumber() int32 {
 func (se *SimpleEpochTriplet) GetBalance() float64 {
 	return se.protoEpochTriplet.GetBalance()
 }
+
+/* epochAttestation is the statement a node signs for its epoch. It ties
+the SimpleEpochTriplet to the digest of the transaction it accompanies
+and to the hash function and network of the snapshot, so an epoch
+signature can't be lifted into a proof for another transaction. Its
+canonical fields are 1 (epoch triplet), 2 (hash ID), 3 (transaction
+digest) and 4 (network ID) */
+type epochAttestation struct {
+	triplet  *SimpleEpochTriplet
+	hashID   string
+	txDigest []byte
+	network  string
+}
+
+/* MarshalCanonical serializes epochAttestation with the canonical
+signing encoding */
+func (ea *epochAttestation) MarshalCanonical() ([]byte, error) {
+	ce := &canonicalEncoder{}
+	ce.putMessage(1, ea.triplet)
+	ce.putString(2, ea.hashID)
+	ce.putBytes(3, ea.txDigest)
+	ce.putString(4, ea.network)
+	return ce.bytes()
+}
+
+/* epochDigest returns the digest a node signs to attest its epoch for
+the transaction with digest txDigest on the given network */
+func epochDigest(triplet *SimpleEpochTriplet, hash crypto.Hash, network string, txDigest []byte) ([]byte, error) {
+	hashID, err := HashID(hash)
+	if err != nil {
+		return nil, err
+	}
+	attestation := &epochAttestation{triplet: triplet, hashID: hashID, txDigest: txDigest, network: network}
+	return digestMarshaler(attestation, EpochDomain, hash)
+}
+
+/* snapshotEpochDigest returns the epoch digest of triplet for the
+transaction held by snapshot */
+func snapshotEpochDigest(triplet *SimpleEpochTriplet, snapshot *SimpleSnapshot, hash crypto.Hash) ([]byte, error) {
+	tDigest, err := transactionDigest(snapshot.GetTransaction(), hash)
+	if err != nil {
+		return nil, err
+	}
+	return epochDigest(triplet, hash, snapshot.GetNetwork(), tDigest)
+}
@@ -0,0 +1,224 @@
+package snapshot
+
+import (
+	"container/heap"
+	"context"
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+/* ReplayStore records the keys of the snapshots a ReplayGuard accepted
+along with the epoch every proving node was at. Implementations may be
+persistent or shared between processes and must be safe for concurrent
+use */
+type ReplayStore interface {
+	/* Record stores keys and the epochs they were proved at as a single
+	record. If any of the keys is already stored it returns false and
+	stores nothing */
+	Record(ctx context.Context, keys []string, epochs map[string]int32) (bool, error)
+	// Prune forgets every record node id proved at an epoch before epoch
+	Prune(ctx context.Context, id string, epoch int32) error
+}
+
+/* MemoryReplayStore is an in-memory ReplayStore. The records of every
+node are queued by the epoch they were proved at, so pruning only looks
+at the records it forgets */
+type MemoryReplayStore struct {
+	mutex   sync.Mutex
+	records map[string]*replayRecord
+	queues  map[string]*replayQueue
+}
+
+// replayRecord is a single record of a MemoryReplayStore
+type replayRecord struct {
+	keys   []string
+	epochs map[string]int32
+	// pruned is set once the record is forgotten through any of its nodes
+	pruned bool
+}
+
+// NewMemoryReplayStore returns an empty MemoryReplayStore
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{records: make(map[string]*replayRecord), queues: make(map[string]*replayQueue)}
+}
+
+// Record stores keys unless one of them is already stored
+func (ms *MemoryReplayStore) Record(ctx context.Context, keys []string, epochs map[string]int32) (bool, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	for _, key := range keys {
+		if _, ok := ms.records[key]; ok {
+			return false, nil
+		}
+	}
+	record := &replayRecord{keys: keys, epochs: epochs}
+	for _, key := range keys {
+		ms.records[key] = record
+	}
+	for id, epoch := range epochs {
+		queue, ok := ms.queues[id]
+		if !ok {
+			queue = &replayQueue{}
+			ms.queues[id] = queue
+		}
+		heap.Push(queue, replayEntry{epoch: epoch, record: record})
+	}
+	return true, nil
+}
+
+/* Prune forgets every record node id proved before epoch. Records
+already forgotten through another node are only dropped from the queue */
+func (ms *MemoryReplayStore) Prune(ctx context.Context, id string, epoch int32) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	queue, ok := ms.queues[id]
+	if !ok {
+		return nil
+	}
+	for queue.Len() > 0 && (*queue)[0].epoch < epoch {
+		record := heap.Pop(queue).(replayEntry).record
+		if record.pruned {
+			continue
+		}
+		record.pruned = true
+		for _, key := range record.keys {
+			delete(ms.records, key)
+		}
+	}
+	if queue.Len() == 0 {
+		delete(ms.queues, id)
+	}
+	return nil
+}
+
+// replayEntry is a record a node proved at epoch
+type replayEntry struct {
+	epoch  int32
+	record *replayRecord
+}
+
+// replayQueue is a heap of the records of a node, oldest epoch first
+type replayQueue []replayEntry
+
+func (rq replayQueue) Len() int            { return len(rq) }
+func (rq replayQueue) Less(i, j int) bool  { return rq[i].epoch < rq[j].epoch }
+func (rq replayQueue) Swap(i, j int)       { rq[i], rq[j] = rq[j], rq[i] }
+func (rq *replayQueue) Push(x interface{}) { *rq = append(*rq, x.(replayEntry)) }
+
+func (rq *replayQueue) Pop() interface{} {
+	old := *rq
+	entry := old[len(old)-1]
+	*rq = old[:len(old)-1]
+	return entry
+}
+
+/* ReplayGuard rejects snapshots whose transaction was already accepted.
+It records the content address of the transaction, which every valid
+transaction ID is a form of, and the digest of the snapshot. Epochs are
+counted per node, so the retention window is too. A record is kept while
+every node that validly proved it is within the window of the latest
+epoch that node validly proved, and a snapshot proved by a node outside
+its window is rejected since its record may be gone. Only proofs the
+verification report marks valid are used so forged proofs can't move
+the window. It is safe for concurrent use */
+type ReplayGuard struct {
+	store  ReplayStore
+	window int32
+
+	mutex  sync.Mutex
+	latest map[string]int32
+}
+
+/* NewReplayGuard returns a ReplayGuard recording into store. Records are
+kept for window epochs behind the latest epoch of the nodes that proved
+them, a window of 0 keeps them forever */
+func NewReplayGuard(store ReplayStore, window int32) *ReplayGuard {
+	return &ReplayGuard{store: store, window: window, latest: make(map[string]int32)}
+}
+
+/* Accept records a snapshot that passed verification with the given
+report. A ReplayErr is returned if its transaction or the snapshot
+itself was accepted before, or if a node proved it outside its window.
+If the snapshot failed verification its error is returned and if report
+belongs to another snapshot a ReportErr */
+func (rg *ReplayGuard) Accept(ctx context.Context, snapshot *SimpleSnapshot, report *VerificationReport) error {
+	proven, err := report.validEpochs(snapshot)
+	if err != nil {
+		return err
+	}
+	epochs := make(map[string]int32)
+	for _, epoch := range proven {
+		epochs[epoch.GetId()] = epoch.GetEpochNumber()
+	}
+	if len(epochs) == 0 {
+		return replayErr(errors.New("snapshot holds no valid proof"))
+	}
+	keys, err := replayKeys(snapshot)
+	if err != nil {
+		return err
+	}
+
+	rg.mutex.Lock()
+	defer rg.mutex.Unlock()
+	for id, epoch := range epochs {
+		if latest, ok := rg.latest[id]; ok && epoch < rg.cutoff(latest) {
+			return replayErr(fmt.Errorf("%q proved epoch %d, outside the retention window", id, epoch))
+		}
+	}
+	fresh, err := rg.store.Record(ctx, keys, epochs)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return replayErr(errors.New("snapshot or transaction was already accepted"))
+	}
+
+	for id, epoch := range epochs {
+		if latest, ok := rg.latest[id]; ok && epoch <= latest {
+			continue
+		}
+		rg.latest[id] = epoch
+		if rg.window > 0 {
+			if err := rg.store.Prune(ctx, id, rg.cutoff(epoch)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/* cutoff returns the oldest epoch inside the retention window of a node
+whose latest epoch is latest. Without a window every epoch is inside it */
+func (rg *ReplayGuard) cutoff(latest int32) int32 {
+	if rg.window <= 0 {
+		return math.MinInt32
+	}
+	cutoff := int64(latest) - int64(rg.window)
+	if cutoff < math.MinInt32 {
+		return math.MinInt32
+	}
+	return int32(cutoff)
+}
+
+/* replayKeys returns the keys a ReplayGuard records for a snapshot, the
+SHA-256 content address of its transaction and its digest */
+func replayKeys(snapshot *SimpleSnapshot) ([]string, error) {
+	id, err := snapshot.GetTransaction().ComputeId(crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := digestMarshaler(snapshot, SnapshotDomain, crypto.SHA256)
+	if err != nil {
+		return nil, &DigestErr{simpleErr{err: err, msg: "replayKeys()"}}
+	}
+	return []string{"tx:" + id, "snapshot:" + hex.EncodeToString(digest)}, nil
+}
+
+// replayErr wraps err in a ReplayErr
+func replayErr(err error) error {
+	return &ReplayErr{simpleErr{err: err, msg: "Transaction replayed"}}
+}
@@ -0,0 +1,120 @@
+package snapshot
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestReplayGuard(t *testing.T) {
+	signers := []crypto.Signer{testKey(t, 0), testKey(t, 1), testKey(t, 2)}
+	keys := MapKeyResolver{}
+	for i, signer := range signers {
+		keys[strconv.Itoa(i)] = signer.Public()
+	}
+	ctx := context.Background()
+	sv := &SnapshotVerifier{Pass: 0.5, Keys: keys, Verifier: pkcsVerifier,
+		Replay: NewReplayGuard(NewMemoryReplayStore(), 2)}
+
+	// A snapshot that fails verification isn't recorded
+	first := createSnapshot(t, createTransaction(1, 1, 10, "ID1", "ID2"), testProof{"0", 1, 5, signers[0]})
+	wrongKeys := *sv
+	wrongKeys.Keys = MapKeyResolver{"0": &testKey(t, 1).PublicKey}
+	if err := wrongKeys.Verify(first); err == nil {
+		t.Fatal("snapshot verified with the wrong key")
+	}
+	if err := sv.Verify(first); err != nil {
+		t.Fatalf("first submission rejected: %v", err)
+	}
+
+	var replay *ReplayErr
+	if err := sv.Verify(first); !errors.As(err, &replay) {
+		t.Fatalf("expected ReplayErr for a resubmission, got %v", err)
+	}
+	if err := sv.VerifyContext(ctx, first); !errors.As(err, &replay) {
+		t.Fatalf("expected ReplayErr from VerifyContext, got %v", err)
+	}
+	// A report of another snapshot can't get a snapshot recorded
+	var reportErr *ReportErr
+	other := createSnapshot(t, createTransaction(9, 1, 10, "ID1", "ID2"), testProof{"1", 1, 5, signers[0]})
+	unguarded := *sv
+	unguarded.Replay = nil
+	if err := sv.Replay.Accept(ctx, other, unguarded.Report(first)); !errors.As(err, &reportErr) {
+		t.Fatalf("expected ReportErr for a mismatched report, got %v", err)
+	}
+	// New proofs over the same transaction are still a replay
+	reproved := createSnapshot(t, first.GetTransaction(), testProof{"0", 2, 5, signers[0]})
+	if err := sv.Verify(reproved); !errors.As(err, &replay) {
+		t.Fatalf("expected ReplayErr for re-proved transaction, got %v", err)
+	}
+
+	// A forged proof claiming a huge epoch doesn't move node 2's window
+	forged := createSnapshot(t, createTransaction(2, 1, 10, "ID1", "ID2"),
+		testProof{"0", 2, 5, signers[0]}, testProof{"2", math.MaxInt32, 5, signers[0]})
+	if err := sv.VerifyContext(ctx, forged); err != nil {
+		t.Fatalf("passing snapshot with a forged proof rejected: %v", err)
+	}
+	// A node far ahead of the others doesn't push them out of their windows
+	ahead := createSnapshot(t, createTransaction(3, 1, 10, "ID1", "ID2"), testProof{"1", 1000, 5, signers[1]})
+	if err := sv.Verify(ahead); err != nil {
+		t.Fatal(err)
+	}
+	honest := createSnapshot(t, createTransaction(4, 1, 10, "ID1", "ID2"),
+		testProof{"0", 3, 5, signers[0]}, testProof{"2", 5, 5, signers[2]})
+	if err := sv.Verify(honest); err != nil {
+		t.Fatalf("honest snapshot rejected: %v", err)
+	}
+
+	// Node 0 is at epoch 3, so its window of 2 starts at epoch 1
+	inside := createSnapshot(t, createTransaction(5, 1, 10, "ID1", "ID2"), testProof{"0", 1, 5, signers[0]})
+	if err := sv.Verify(inside); err != nil {
+		t.Fatalf("snapshot inside the window rejected: %v", err)
+	}
+	late := createSnapshot(t, createTransaction(6, 1, 10, "ID1", "ID2"), testProof{"0", 0, 5, signers[0]})
+	if err := sv.Verify(late); !errors.As(err, &replay) {
+		t.Fatalf("expected ReplayErr outside the window, got %v", err)
+	}
+}
+
+func TestMemoryReplayStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryReplayStore()
+	record := func(keys []string, epochs map[string]int32) bool {
+		fresh, err := store.Record(ctx, keys, epochs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fresh
+	}
+	if !record([]string{"a", "b"}, map[string]int32{"x": 1, "y": 5}) {
+		t.Fatal("first record rejected")
+	}
+	// Records are all or nothing
+	if record([]string{"c", "b"}, map[string]int32{"x": 2}) {
+		t.Fatal("record with a stored key accepted")
+	}
+	if !record([]string{"c"}, map[string]int32{"x": 2}) {
+		t.Fatal("rejected record left its keys behind")
+	}
+
+	// Pruning any node that proved a record forgets all of its keys
+	if err := store.Prune(ctx, "y", 6); err != nil {
+		t.Fatal(err)
+	}
+	if !record([]string{"a"}, nil) || !record([]string{"b"}, nil) {
+		t.Fatal("pruned keys still stored")
+	}
+	if record([]string{"c"}, nil) {
+		t.Fatal("record of another node was pruned")
+	}
+	// A record already pruned through another node doesn't take newer keys with it
+	if err := store.Prune(ctx, "x", 3); err != nil {
+		t.Fatal(err)
+	}
+	if record([]string{"a"}, nil) || !record([]string{"c"}, nil) {
+		t.Fatal("pruning x forgot the wrong records")
+	}
+}
@@ -0,0 +1,205 @@
+package snapshot
+
+import (
+	"fmt"
+)
+
+/* ProofResult describes the outcome of verifying a single
+SimpleProofTuple */
+type ProofResult struct {
+	// NodeId is the Node Id claimed by the proof
+	NodeId string
+	// Role is the part the node played in the transaction
+	Role ProofRole
+	// Weight is how much the proof counts towards the Pass quorum
+	Weight float64
+	/* KeyFound is false if the KeyResolver had no key for NodeId or
+	failed, or if the key couldn't be tied to NodeId by its fingerprint
+	or certificate. Both signature errors then hold the reason */
+	KeyFound bool
+	// TransactionErr is nil if the transaction signature is valid
+	TransactionErr error
+	// EpochErr is nil if the epoch signature is valid
+	EpochErr error
+	/* Revoked is true if the node's key was revoked at the epoch of the
+	proof. Both signature errors then hold a RevokedSignerErr */
+	Revoked bool
+	// Legacy is true if the proof only verified as a legacy proof
+	Legacy bool
+	/* Skipped is true if the proof was never checked because the
+	outcome of the snapshot was already known */
+	Skipped bool
+	// Ignored is true if the verification policy left the proof out
+	Ignored bool
+	/* Duplicate is true if an identical proof appears earlier in the
+	snapshot, or if the proof is valid and an earlier valid proof of the
+	node signs the same epoch with the same hash. Duplicates are not
+	counted and identical ones aren't checked */
+	Duplicate bool
+	/* Conflict is true if the node has another valid proof in the
+	snapshot that signs a different epoch or hash. Conflicting proofs
+	never count as valid */
+	Conflict bool
+
+	// statement identifies what the proof signed once it is valid
+	statement proofStatement
+}
+
+// Valid returns whether both signatures of the proof are valid
+func (pr *ProofResult) Valid() bool {
+	return pr.counted() && !pr.Skipped && !pr.Conflict && pr.TransactionErr == nil && pr.EpochErr == nil
+}
+
+// counted returns whether the proof takes part in verification at all
+func (pr *ProofResult) counted() bool {
+	return !pr.Ignored && !pr.Duplicate
+}
+
+/* ProofConflict is evidence that a node signed two different valid
+proofs for the same snapshot */
+type ProofConflict struct {
+	NodeId string
+	First  *SimpleProofTuple
+	Second *SimpleProofTuple
+}
+
+/* VerificationReport holds the outcome of verifying a SimpleSnapshot,
+with one ProofResult per SimpleProofTuple in the order they appear in
+the snapshot */
+type VerificationReport struct {
+	Proofs []ProofResult
+	// Conflicts holds evidence of every node that signed conflicting proofs
+	Conflicts []ProofConflict
+	// Passed is the number of valid proofs
+	Passed int
+	// Total is the number of proofs in the snapshot
+	Total int
+	// Skipped is the number of proofs that were never checked
+	Skipped int
+	// Ignored is the number of proofs left out by the verification policy
+	Ignored int
+	// Duplicates is the number of identical copies of earlier proofs
+	Duplicates int
+	// Revoked is the number of proofs made with a revoked key
+	Revoked int
+	/* PassedWeight is the total weight of the valid nodes. Each node
+	counts once no matter how many proofs it has in the snapshot */
+	PassedWeight float64
+	/* TotalWeight is the total weight of the nodes that weren't ignored,
+	valid or not. With AttestedBalanceWeight a node only weighs what one
+	of its proofs with a valid epoch signature attests, nodes that were
+	never checked count with their claimed weight */
+	TotalWeight float64
+	/* Ratio is PassedWeight divided by TotalWeight, or 0 if there is no
+	weight at all. Without a WeightFunc this is the fraction of nodes that
+	weren't ignored and are valid */
+	Ratio float64
+	/* Err is nil if the snapshot passed verification. It is also set if
+	the snapshot could not be checked at all, in which case Proofs is
+	empty */
+	Err error
+}
+
+/* newReport builds the report for a snapshot from the results of its
+proofs and the tally they were added to. Valid proofs signing the same
+statement as an earlier valid proof of their node are marked as
+duplicates, nodes with valid proofs of different statements have their
+proofs marked as conflicts */
+func newReport(proofs []*SimpleProofTuple, results []ProofResult, t *tally) *VerificationReport {
+	report := &VerificationReport{Proofs: results}
+	firstValid := make(map[string]int)
+	for i := range results {
+		result := &results[i]
+		if !result.Valid() {
+			continue
+		}
+		first, ok := firstValid[result.NodeId]
+		if !ok {
+			firstValid[result.NodeId] = i
+			continue
+		}
+		if result.statement == results[first].statement {
+			result.Duplicate = true
+			continue
+		}
+		if !results[first].Conflict {
+			results[first].Conflict = true
+			report.Conflicts = append(report.Conflicts, ProofConflict{
+				NodeId: result.NodeId,
+				First:  proofs[first],
+				Second: proofs[i],
+			})
+		}
+		result.Conflict = true
+	}
+
+	for _, result := range results {
+		report.add(result)
+	}
+	report.PassedWeight = t.counted.passed
+	report.TotalWeight = t.total
+	if report.TotalWeight > 0 {
+		report.Ratio = report.PassedWeight / report.TotalWeight
+	}
+	report.Err = t.err()
+	return report
+}
+
+// add counts the result of a single proof
+func (vr *VerificationReport) add(result ProofResult) {
+	vr.Total++
+	switch {
+	case result.Ignored:
+		vr.Ignored++
+	case result.Duplicate:
+		vr.Duplicates++
+	case result.Skipped:
+		vr.Skipped++
+	case result.Revoked:
+		vr.Revoked++
+	case result.Valid():
+		vr.Passed++
+	}
+}
+
+/* validEpochs returns the epochs of the proofs of snapshot that the
+report marks valid. If the snapshot failed verification its error is
+returned. A ReportErr is returned unless the report holds one result
+per proof of snapshot with the proof's Node Id */
+func (vr *VerificationReport) validEpochs(snapshot *SimpleSnapshot) ([]*SimpleEpochTriplet, error) {
+	if vr.Err != nil {
+		return nil, vr.Err
+	}
+	proofs := snapshot.GetProofs()
+	if len(vr.Proofs) != len(proofs) {
+		return nil, reportErr(fmt.Errorf("%d results for %d proofs", len(vr.Proofs), len(proofs)))
+	}
+	var epochs []*SimpleEpochTriplet
+	for i, proof := range proofs {
+		epoch := proof.GetEpoch()
+		if vr.Proofs[i].NodeId != epoch.GetId() {
+			return nil, reportErr(fmt.Errorf("result %d is for %q, proof for %q", i, vr.Proofs[i].NodeId, epoch.GetId()))
+		}
+		if vr.Proofs[i].Valid() {
+			epochs = append(epochs, epoch)
+		}
+	}
+	return epochs, nil
+}
+
+/* Failed returns the results of every checked proof that did not verify
+so operators can see which nodes misbehaved */
+func (vr *VerificationReport) Failed() []ProofResult {
+	failed := make([]ProofResult, 0, len(vr.Proofs)-vr.Passed)
+	for _, result := range vr.Proofs {
+		if result.counted() && !result.Skipped && !result.Valid() {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// reportErr wraps err in a ReportErr
+func reportErr(err error) error {
+	return &ReportErr{simpleErr{err: err, msg: "Report doesn't match snapshot"}}
+}
@@ -0,0 +1,171 @@
+package snapshot
+
+import (
+	"crypto"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+/* RevocationEntry distrusts every proof a node makes from Epoch
+onwards, usually because its key was compromised at that epoch */
+type RevocationEntry struct {
+	NodeId string
+	Epoch  int32
+	Reason string
+}
+
+/* SimpleRevocationList implements a signed and versioned list of
+revoked node keys. Each published list should carry a higher version
+than the one before it so consumers can tell which list is newer */
+type SimpleRevocationList struct {
+	protoRevocationList *RevocationList
+}
+
+// NewSimpleRevocationList returns an empty, unsigned SimpleRevocationList
+func NewSimpleRevocationList(version uint64) *SimpleRevocationList {
+	return &SimpleRevocationList{
+		protoRevocationList: &RevocationList{Version: version},
+	}
+}
+
+/* Revoke distrusts the proofs of node id from epoch onwards. The list
+has to be signed again afterwards */
+func (rl *SimpleRevocationList) Revoke(id string, epoch int32, reason string) {
+	entries := rl.protoRevocationList.GetEntries()
+	entries = append(entries, &RevocationList_Entry{Id: id, Epoch: epoch, Reason: reason})
+	rl.protoRevocationList.Entries = entries
+}
+
+// Sign signs the list using the passed in signer with the given hash
+func (rl *SimpleRevocationList) Sign(hash crypto.Hash, signer crypto.Signer) error {
+	hashID, err := HashID(hash)
+	if err != nil {
+		return err
+	}
+	rl.protoRevocationList.Hash = hashID
+	digest, err := digestMarshaler(rl, RevocationDomain, hash)
+	if err != nil {
+		return &DigestErr{simpleErr{err: err, msg: "SimpleRevocationList.Sign()"}}
+	}
+	sig, err := signDigest(signer, digest, hash)
+	if err != nil {
+		return &SignatureErr{simpleErr{err: err, msg: "SimpleRevocationList.Sign()"}}
+	}
+	rl.protoRevocationList.Sign = sig
+	return nil
+}
+
+/* Verify checks that the list was signed by the owner of the provided
+public key */
+func (rl *SimpleRevocationList) Verify(pk crypto.PublicKey, verf Verifier) error {
+	if missingKey(pk) {
+		return unknownSignerErr("")
+	}
+	hash, err := HashFromID(rl.protoRevocationList.GetHash())
+	if err != nil {
+		return err
+	}
+	digest, err := digestMarshaler(rl, RevocationDomain, hash)
+	if err != nil {
+		return &DigestErr{simpleErr{err: err, msg: "SimpleRevocationList.Verify()"}}
+	}
+	if err := verf(pk, hash, digest, rl.protoRevocationList.GetSign()); err != nil {
+		return &VerificationErr{simpleErr{err: err, msg: "SimpleRevocationList.Verify()"}}
+	}
+	return nil
+}
+
+// Marshal serializes SimpleRevocationList into a slice of bytes
+func (rl *SimpleRevocationList) Marshal() ([]byte, error) {
+	out, err := proto.Marshal(rl.protoRevocationList)
+	if err != nil {
+		return out, &MarshalErr{simpleErr{err: err, msg: "SimpleRevocationList.Marshal()"}}
+	}
+	return out, nil
+}
+
+/* MarshalCanonical serializes the signed fields of SimpleRevocationList
+with the canonical signing encoding. The signature itself is left out */
+func (rl *SimpleRevocationList) MarshalCanonical() ([]byte, error) {
+	list := rl.protoRevocationList
+	entries := make([]canonicalMarshaler, 0, len(list.GetEntries()))
+	for _, entry := range list.GetEntries() {
+		entries = append(entries, revocationEntry{entry})
+	}
+
+	ce := &canonicalEncoder{}
+	ce.putUint64(1, list.GetVersion())
+	ce.putMessages(2, entries)
+	ce.putString(3, list.GetHash())
+	return ce.bytes()
+}
+
+// Unmarshal deserializes SimpleRevocationList from a slice of bytes
+func (rl *SimpleRevocationList) Unmarshal(serial []byte) error {
+	rl.protoRevocationList = &RevocationList{}
+	if err := proto.Unmarshal(serial, rl.protoRevocationList); err != nil {
+		return &MarshalErr{simpleErr{err: err, msg: "SimpleRevocationList.Unmarshal()"}}
+	}
+	return nil
+}
+
+// GetVersion returns the version of the list
+func (rl *SimpleRevocationList) GetVersion() uint64 {
+	return rl.protoRevocationList.GetVersion()
+}
+
+// GetEntries returns every revocation in the list
+func (rl *SimpleRevocationList) GetEntries() []RevocationEntry {
+	entries := make([]RevocationEntry, 0, len(rl.protoRevocationList.GetEntries()))
+	for _, entry := range rl.protoRevocationList.GetEntries() {
+		entries = append(entries, RevocationEntry{
+			NodeId: entry.GetId(),
+			Epoch:  entry.GetEpoch(),
+			Reason: entry.GetReason(),
+		})
+	}
+	return entries
+}
+
+/* Revoked returns the revocation distrusting node id at epoch, if any.
+When several entries apply the one with the earliest epoch is returned */
+func (rl *SimpleRevocationList) Revoked(id string, epoch int32) (RevocationEntry, bool) {
+	var found *RevocationList_Entry
+	for _, entry := range rl.protoRevocationList.GetEntries() {
+		if entry.GetId() != id || entry.GetEpoch() > epoch {
+			continue
+		}
+		if found == nil || entry.GetEpoch() < found.GetEpoch() {
+			found = entry
+		}
+	}
+	if found == nil {
+		return RevocationEntry{}, false
+	}
+	return RevocationEntry{NodeId: found.GetId(), Epoch: found.GetEpoch(), Reason: found.GetReason()}, true
+}
+
+/* revocationEntry gives RevocationList_Entry its canonical encoding.
+Its fields are 1 (Node Id), 2 (epoch) and 3 (reason) */
+type revocationEntry struct {
+	entry *RevocationList_Entry
+}
+
+// MarshalCanonical serializes revocationEntry with the canonical signing encoding
+func (re revocationEntry) MarshalCanonical() ([]byte, error) {
+	ce := &canonicalEncoder{}
+	ce.putString(1, re.entry.GetId())
+	ce.putInt32(2, re.entry.GetEpoch())
+	ce.putString(3, re.entry.GetReason())
+	return ce.bytes()
+}
+
+// revokedSignerErr builds the error for a proof made with a revoked key
+func revokedSignerErr(entry RevocationEntry) error {
+	return &RevokedSignerErr{
+		simpleErr: simpleErr{err: fmt.Errorf("%q from epoch %d: %s", entry.NodeId, entry.Epoch, entry.Reason),
+			msg: "Signer was revoked"},
+		Entry: entry,
+	}
+}
@@ -0,0 +1,282 @@
+package snapshot
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+/* SimpleKeyRotation implements a signed record announcing that a node
+signs with a new key from a given epoch onwards. It is signed by the key
+it replaces so only the holder of that key can rotate it */
+type SimpleKeyRotation struct {
+	protoKeyRotation *KeyRotation
+}
+
+/* NewSimpleKeyRotation returns a SimpleKeyRotation moving node id to
+newKey from epoch onwards, signed by the node's current key with the
+given hash function */
+func NewSimpleKeyRotation(id string, epoch int32, newKey crypto.PublicKey, hash crypto.Hash,
+	signer crypto.Signer) (*SimpleKeyRotation, error) {
+	hashID, err := HashID(hash)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(newKey)
+	if err != nil {
+		return nil, &KeyErr{simpleErr{err: err, msg: "NewSimpleKeyRotation()"}}
+	}
+
+	rotation := &SimpleKeyRotation{
+		protoKeyRotation: &KeyRotation{
+			Id:     id,
+			Epoch:  epoch,
+			NewKey: der,
+			Hash:   hashID,
+		},
+	}
+	digest, err := digestMarshaler(rotation, RotationDomain, hash)
+	if err != nil {
+		return nil, &DigestErr{simpleErr{err: err, msg: "NewSimpleKeyRotation()"}}
+	}
+	sig, err := signDigest(signer, digest, hash)
+	if err != nil {
+		return nil, &SignatureErr{simpleErr{err: err, msg: "NewSimpleKeyRotation()"}}
+	}
+	rotation.protoKeyRotation.Sign = sig
+	return rotation, nil
+}
+
+// Marshal serializes SimpleKeyRotation into a slice of bytes
+func (sr *SimpleKeyRotation) Marshal() ([]byte, error) {
+	out, err := proto.Marshal(sr.protoKeyRotation)
+	if err != nil {
+		return out, &MarshalErr{simpleErr{err: err, msg: "SimpleKeyRotation.Marshal()"}}
+	}
+	return out, nil
+}
+
+/* MarshalCanonical serializes the signed fields of SimpleKeyRotation
+with the canonical signing encoding. The signature itself is left out */
+func (sr *SimpleKeyRotation) MarshalCanonical() ([]byte, error) {
+	rotation := sr.protoKeyRotation
+	ce := &canonicalEncoder{}
+	ce.putString(1, rotation.GetId())
+	ce.putInt32(2, rotation.GetEpoch())
+	ce.putBytes(3, rotation.GetNewKey())
+	ce.putString(4, rotation.GetHash())
+	return ce.bytes()
+}
+
+// Unmarshal deserializes SimpleKeyRotation from a slice of bytes
+func (sr *SimpleKeyRotation) Unmarshal(serial []byte) error {
+	sr.protoKeyRotation = &KeyRotation{}
+	if err := proto.Unmarshal(serial, sr.protoKeyRotation); err != nil {
+		return &MarshalErr{simpleErr{err: err, msg: "SimpleKeyRotation.Unmarshal()"}}
+	}
+	return nil
+}
+
+// GetId returns the Node Id whose key is rotated
+func (sr *SimpleKeyRotation) GetId() string {
+	return sr.protoKeyRotation.GetId()
+}
+
+// GetEpochNumber returns the first epoch signed with the new key
+func (sr *SimpleKeyRotation) GetEpochNumber() int32 {
+	return sr.protoKeyRotation.GetEpoch()
+}
+
+// GetNewKey parses and returns the new public key
+func (sr *SimpleKeyRotation) GetNewKey() (crypto.PublicKey, error) {
+	pk, err := x509.ParsePKIXPublicKey(sr.protoKeyRotation.GetNewKey())
+	if err != nil {
+		return nil, &KeyErr{simpleErr{err: err, msg: "SimpleKeyRotation.GetNewKey()"}}
+	}
+	return pk, nil
+}
+
+/* GetHash returns the hash function the rotation was signed with. An
+error is returned if the recorded hash is unknown */
+func (sr *SimpleKeyRotation) GetHash() (crypto.Hash, error) {
+	return HashFromID(sr.protoKeyRotation.GetHash())
+}
+
+// GetSignature returns the signature made by the previous key
+func (sr *SimpleKeyRotation) GetSignature() []byte {
+	return sr.protoKeyRotation.GetSign()
+}
+
+/* Verify checks that the rotation was signed by previous, the key the
+node used before the rotation takes effect */
+func (sr *SimpleKeyRotation) Verify(previous crypto.PublicKey, verf Verifier) error {
+	if missingKey(previous) {
+		return unknownSignerErr(sr.GetId())
+	}
+	hash, err := sr.GetHash()
+	if err != nil {
+		return err
+	}
+	digest, err := digestMarshaler(sr, RotationDomain, hash)
+	if err != nil {
+		return &DigestErr{simpleErr{err: err, msg: "SimpleKeyRotation.Verify()"}}
+	}
+	if err := verf(previous, hash, digest, sr.GetSignature()); err != nil {
+		return &VerificationErr{simpleErr{err: err, msg: "SimpleKeyRotation.Verify()"}}
+	}
+	return nil
+}
+
+/* RotatingKeyResolver is a KeyResolver that follows the key rotations of
+every node. A node's key at a given epoch is the key of the latest
+rotation taking effect at or before that epoch, or the key held by the
+wrapped resolver if there is none. Historical snapshots therefore keep
+verifying after a node rotates. It is a KeyChangeNotifier so caches in
+front of it drop a node's key once a rotation replaces it. It is safe
+for concurrent use */
+type RotatingKeyResolver struct {
+	resolver KeyResolver
+	verf     Verifier
+
+	mutex     sync.RWMutex
+	rotations map[string][]rotatedKey
+	// forgets is called with the Node Id of every added rotation
+	forgets []func(id string)
+}
+
+// rotatedKey is a verified rotation and the key it introduced
+type rotatedKey struct {
+	rotation *SimpleKeyRotation
+	pk       crypto.PublicKey
+}
+
+/* NewRotatingKeyResolver returns a RotatingKeyResolver that starts from
+the keys held by resolver and checks rotations with verf */
+func NewRotatingKeyResolver(resolver KeyResolver, verf Verifier) *RotatingKeyResolver {
+	return &RotatingKeyResolver{
+		resolver:  resolver,
+		verf:      verf,
+		rotations: make(map[string][]rotatedKey),
+	}
+}
+
+/* AddRotation verifies a rotation against the key it replaces and
+records it. Rotations of a node must be added in the order they take
+effect. A RotationErr is returned if the rotation is out of order or
+isn't signed by the key in use just before its epoch */
+func (rr *RotatingKeyResolver) AddRotation(ctx context.Context, rotation *SimpleKeyRotation) error {
+	if err := rr.addRotation(ctx, rotation); err != nil {
+		return err
+	}
+	rr.mutex.RLock()
+	forgets := rr.forgets
+	rr.mutex.RUnlock()
+	for _, forget := range forgets {
+		forget(rotation.GetId())
+	}
+	return nil
+}
+
+// addRotation verifies and records a rotation for AddRotation
+func (rr *RotatingKeyResolver) addRotation(ctx context.Context, rotation *SimpleKeyRotation) error {
+	id, epoch := rotation.GetId(), rotation.GetEpochNumber()
+	if epoch <= 0 {
+		return rotationErr(id, fmt.Errorf("epoch %d is before the first epoch", epoch))
+	}
+	pk, err := rotation.GetNewKey()
+	if err != nil {
+		return rotationErr(id, err)
+	}
+
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	history := rr.rotations[id]
+	var previous crypto.PublicKey
+	if len(history) > 0 {
+		last := history[len(history)-1]
+		if epoch <= last.rotation.GetEpochNumber() {
+			return rotationErr(id, fmt.Errorf("epoch %d does not follow epoch %d", epoch,
+				last.rotation.GetEpochNumber()))
+		}
+		previous = last.pk
+	} else {
+		previous, err = resolveKey(ctx, rr.resolver, id, epoch-1)
+		if err != nil {
+			return rotationErr(id, err)
+		}
+	}
+	if err := rotation.Verify(previous, rr.verf); err != nil {
+		return rotationErr(id, err)
+	}
+	rr.rotations[id] = append(history, rotatedKey{rotation: rotation, pk: pk})
+	return nil
+}
+
+/* NotifyKeyChange registers forget to be called with the Node Id of
+every rotation added from now on */
+func (rr *RotatingKeyResolver) NotifyKeyChange(forget func(id string)) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+	rr.forgets = append(rr.forgets, forget)
+}
+
+/* Rotations returns every rotation recorded for id in the order they
+take effect so they can be stored and added again later */
+func (rr *RotatingKeyResolver) Rotations(id string) []*SimpleKeyRotation {
+	rr.mutex.RLock()
+	defer rr.mutex.RUnlock()
+	history := rr.rotations[id]
+	rotations := make([]*SimpleKeyRotation, 0, len(history))
+	for _, rotated := range history {
+		rotations = append(rotations, rotated.rotation)
+	}
+	return rotations
+}
+
+// ResolveKey returns the key id signed with at epoch
+func (rr *RotatingKeyResolver) ResolveKey(ctx context.Context, id string, epoch int32) (crypto.PublicKey, error) {
+	pk, _, _, err := rr.ResolveKeyRange(ctx, id, epoch)
+	return pk, err
+}
+
+/* ResolveKeyRange returns the key id signed with at epoch and the epochs
+it is used for. The range of the latest key ends when the next rotation
+is added */
+func (rr *RotatingKeyResolver) ResolveKeyRange(ctx context.Context, id string, epoch int32) (crypto.PublicKey, int32, int32, error) {
+	rr.mutex.RLock()
+	history := rr.rotations[id]
+	// Index of the first rotation taking effect after epoch
+	i := sort.Search(len(history), func(i int) bool {
+		return history[i].rotation.GetEpochNumber() > epoch
+	})
+	last := int32(math.MaxInt32)
+	if i < len(history) {
+		last = history[i].rotation.GetEpochNumber() - 1
+	}
+	if i > 0 {
+		pk, first := history[i-1].pk, history[i-1].rotation.GetEpochNumber()
+		rr.mutex.RUnlock()
+		return pk, first, last, nil
+	}
+	rr.mutex.RUnlock()
+
+	if rr.resolver == nil {
+		return nil, 0, 0, unknownSignerErr(id)
+	}
+	pk, first, resolverLast, err := resolveKeyRange(ctx, rr.resolver, id, epoch)
+	if resolverLast < last {
+		last = resolverLast
+	}
+	return pk, first, last, err
+}
+
+// rotationErr wraps err in a RotationErr for the rotation of node id
+func rotationErr(id string, err error) error {
+	return &RotationErr{simpleErr{err: err, msg: fmt.Sprintf("Rotating key of %q", id)}}
+}
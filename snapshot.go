@@ -1,6 +1,9 @@
 package snapshot
 
 import (
+	"crypto"
+	"fmt"
+
 	"google.golang.org/protobuf/proto"
 )
 
@@ -10,11 +13,38 @@ type SimpleSnapshot struct {
 	protoSnapshot *Snapshot
 }
 
-// NewSimpleSnapshot returns an empty instance of a SimpleSnapshot
+/* NewSimpleSnapshot returns an empty instance of a SimpleSnapshot
+that records ProofHashFunc as its hash function. The snapshot takes its
+network ID from the transaction */
 func NewSimpleSnapshot(tx *SimpleTransaction) *SimpleSnapshot {
+	hashID, err := HashID(ProofHashFunc)
+	if err != nil {
+		// Keep the name so verification reports the unsupported hash
+		hashID = ProofHashFunc.String()
+	}
 	return &SimpleSnapshot{
-		protoSnapshot: &Snapshot{Transaction: tx.protoTransaction},
+		protoSnapshot: &Snapshot{
+			Transaction: tx.protoTransaction,
+			Hash:        hashID,
+			Network:     tx.GetNetwork(),
+		},
+	}
+}
+
+/* NewSimpleSnapshotWithHash returns an empty instance of a SimpleSnapshot
+whose proofs are created and verified with the given hash function */
+func NewSimpleSnapshotWithHash(tx *SimpleTransaction, hash crypto.Hash) (*SimpleSnapshot, error) {
+	hashID, err := HashID(hash)
+	if err != nil {
+		return nil, err
 	}
+	return &SimpleSnapshot{
+		protoSnapshot: &Snapshot{
+			Transaction: tx.protoTransaction,
+			Hash:        hashID,
+			Network:     tx.GetNetwork(),
+		},
+	}, nil
 }
 
 // Marshal serializes a SimpleSnapshot into a slice of bytes
@@ -26,6 +56,23 @@ func (ss *SimpleSnapshot) Marshal() ([]byte, error) {
 	return out, nil
 }
 
+/* MarshalCanonical serializes a SimpleSnapshot with the canonical
+signing encoding */
+func (ss *SimpleSnapshot) MarshalCanonical() ([]byte, error) {
+	snapshot := ss.protoSnapshot
+	proofs := make([]canonicalMarshaler, 0, len(snapshot.GetProofs()))
+	for _, proof := range ss.GetProofs() {
+		proofs = append(proofs, proof)
+	}
+
+	ce := &canonicalEncoder{}
+	ce.putMessage(1, ss.GetTransaction())
+	ce.putString(2, snapshot.GetHash())
+	ce.putMessages(3, proofs)
+	ce.putString(4, snapshot.GetNetwork())
+	return ce.bytes()
+}
+
 // Unmarshal deserializes a slice of bytes into a SimpleSnapshot
 func (ss *SimpleSnapshot) Unmarshal(serial []byte) error {
 	ss.protoSnapshot = &Snapshot{}
@@ -45,12 +92,40 @@ func (ss *SimpleSnapshot) GetTransaction() *SimpleTransaction {
 	}
 }
 
+/* GetHash returns the hash function recorded in the snapshot. An error
+is returned if the recorded hash is unknown */
+func (ss *SimpleSnapshot) GetHash() (crypto.Hash, error) {
+	return HashFromID(ss.protoSnapshot.GetHash())
+}
+
+// GetNetwork returns the ID of the network the snapshot belongs to
+func (ss *SimpleSnapshot) GetNetwork() string {
+	return ss.protoSnapshot.GetNetwork()
+}
+
 /* AddProof adds a SimpleProofTuple to the SimpleSnapshot for later
-verification */
-func (ss *SimpleSnapshot) AddProof(proof *SimpleProofTuple) {
+verification. Each node may only add one proof. A DuplicateProofErr is
+returned if the node already added the same proof and a
+ConflictingProofErr if it added a different one */
+func (ss *SimpleSnapshot) AddProof(proof *SimpleProofTuple) error {
+	id := proof.GetEpoch().GetId()
+	for _, existing := range ss.GetProofs() {
+		if existing.GetEpoch().GetId() != id {
+			continue
+		}
+		if existing.Equal(proof) {
+			return &DuplicateProofErr{simpleErr{err: fmt.Errorf("%q", id), msg: "Proof already added"}}
+		}
+		return &ConflictingProofErr{
+			simpleErr: simpleErr{err: fmt.Errorf("%q", id), msg: "Node already added a different proof"},
+			Conflict:  ProofConflict{NodeId: id, First: existing, Second: proof},
+		}
+	}
+
 	proofs := ss.protoSnapshot.GetProofs()
 	proofs = append(proofs, proof.protoProofTuple)
 	ss.protoSnapshot.Proofs = proofs
+	return nil
 }
 
 /* GetProofs returns all SimpleProofTuples currently held by
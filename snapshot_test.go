@@ -2,12 +2,21 @@ package snapshot
 
 import (
 	"bytes"
+	"context"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 	"testing"
+
+	"google.golang.org/protobuf/proto"
 )
 
 //TRANSACTION
@@ -64,6 +73,29 @@ func createTransaction(a int32, r float64, e float64, g string, l string) *Simpl
 	return tx
 }
 
+// testProof describes a proof made by createSnapshot
+type testProof struct {
+	id      string
+	epoch   int32
+	balance float64
+	signer  crypto.Signer
+}
+
+// createSnapshot returns a snapshot of tx holding the given proofs
+func createSnapshot(t *testing.T, tx *SimpleTransaction, proofs ...testProof) *SimpleSnapshot {
+	snapshot := NewSimpleSnapshot(tx)
+	for _, p := range proofs {
+		tup, err := NewSimpleProofTuple(tx, p.id, p.epoch, p.balance, p.signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := snapshot.AddProof(tup); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return snapshot
+}
+
 //SNAPSHOT
 func TestSnapshot(t *testing.T) {
 	totalKeys := 8
@@ -92,7 +124,7 @@ func TestSnapshot(t *testing.T) {
 			balance := float64(count)*3.5 + 1
 			tup, _ := NewSimpleProofTuple(tx, id, 1, balance, key)
 			if count%10 == 0 {
-				tup.protoProofTuple.EpochSign = ""
+				tup.protoProofTuple.EpochSign = nil
 			}
 			snapshot.AddProof(tup)
 			count++
@@ -117,3 +149,710 @@ func pkcsVerifier(key crypto.PublicKey, hash crypto.Hash, digest []byte, sig []b
 	rsaKey := (key).(*rsa.PublicKey)
 	return rsa.VerifyPKCS1v15(rsaKey, hash, digest, sig)
 }
+
+//DIGEST
+func TestDigestCoversWholeTransaction(t *testing.T) {
+	tx1 := createTransaction(1, 0.5, 2, "ID1", "ID2")
+	tx1.SetBystanders([]string{"bystander-with-a-long-shared-prefix-0", "tail-1"})
+	tx2 := createTransaction(1, 0.5, 2, "ID1", "ID2")
+	tx2.SetBystanders([]string{"bystander-with-a-long-shared-prefix-0", "tail-2"})
+
+	d1, err := transactionDigest(tx1, ProofHashFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2, err := transactionDigest(tx2, ProofHashFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(d1, d2) {
+		t.Fatal("transactions sharing a prefix produced the same digest")
+	}
+	if len(d1) != ProofHashFunc.Size() {
+		t.Fatalf("digest has %d bytes, expected %d", len(d1), ProofHashFunc.Size())
+	}
+
+	e1, err := digestMarshaler(tx1, EpochDomain, ProofHashFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(d1, e1) {
+		t.Fatal("digests for different domains are equal")
+	}
+
+	l1, _ := legacyDigestMarshaler(tx1, ProofHashFunc)
+	l2, _ := legacyDigestMarshaler(tx2, ProofHashFunc)
+	if !bytes.Equal(l1, l2) {
+		t.Fatal("legacy digest no longer matches the old truncation behavior")
+	}
+}
+
+func TestLegacySnapshotCompat(t *testing.T) {
+	key := testKey(t, 0)
+	tx := createTransaction(7, 0.1, 3, "ID1", "ID2")
+	snapshot := NewSimpleSnapshot(tx)
+	snapshot.AddProof(legacyProofTuple(t, tx, "0", 1, 10, key))
+
+	keys := map[string]crypto.PublicKey{"0": &key.PublicKey}
+	if err := VerifySnapshot(1, snapshot, keys, pkcsVerifier); err == nil {
+		t.Fatal("legacy proof verified without AllowLegacy")
+	}
+
+	sv := &SnapshotVerifier{Pass: 1, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier, AllowLegacy: true}
+	if err := sv.Verify(snapshot); err != nil {
+		t.Fatalf("legacy proof rejected with AllowLegacy: %v", err)
+	}
+}
+
+// legacyProofTuple signs a proof the way releases before the digest fix did
+func legacyProofTuple(t *testing.T, tx *SimpleTransaction, id string, epoch int32, balance float64,
+	key *rsa.PrivateKey) *SimpleProofTuple {
+	triplet := NewSimpleEpochTriplet(id, epoch, balance)
+	tDigest, err := legacyDigestMarshaler(tx, ProofHashFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eDigest, err := legacyDigestMarshaler(triplet, ProofHashFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tSig, err := key.Sign(rand.Reader, tDigest, ProofHashFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eSig, err := key.Sign(rand.Reader, eDigest, ProofHashFunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &SimpleProofTuple{
+		protoProofTuple: &Snapshot_ProofTuple{
+			Epoch:           triplet.protoEpochTriplet,
+			TransactionSign: []byte(base64.StdEncoding.EncodeToString(tSig)),
+			EpochSign:       []byte(base64.StdEncoding.EncodeToString(eSig)),
+		},
+	}
+}
+
+//HASH
+func TestSnapshotHashAgility(t *testing.T) {
+	key := testKey(t, 0)
+	keys := map[string]crypto.PublicKey{"0": &key.PublicKey}
+	hashes := []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512, crypto.SHA3_256}
+
+	snapshots := make([]*SimpleSnapshot, 0, len(hashes))
+	for i, hash := range hashes {
+		tx := createTransaction(int32(i), 1, 2, "ID1", "ID2")
+		snapshot, err := NewSimpleSnapshotWithHash(tx, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tup, err := NewSimpleProofTupleWithHash(tx, "0", 1, 5, hash, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot.AddProof(tup)
+
+		raw, err := snapshot.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := &SimpleSnapshot{}
+		if err := decoded.Unmarshal(raw); err != nil {
+			t.Fatal(err)
+		}
+		snapshots = append(snapshots, decoded)
+	}
+
+	for i, snapshot := range snapshots {
+		if hash, _ := snapshot.GetHash(); hash != hashes[i] {
+			t.Fatalf("snapshot %d recorded %v, expected %v", i, hash, hashes[i])
+		}
+		if err := VerifySnapshot(1, snapshot, keys, pkcsVerifier); err != nil {
+			t.Fatalf("snapshot hashed with %v failed: %v", hashes[i], err)
+		}
+	}
+
+	snapshots[0].protoSnapshot.Proofs[0].Hash = HashSHA512
+	if err := VerifySnapshot(1, snapshots[0], keys, pkcsVerifier); err == nil {
+		t.Fatal("proof verified with the wrong hash ID")
+	}
+	snapshots[0].protoSnapshot.Hash = "md5"
+	var hashErr *HashErr
+	if err := VerifySnapshot(1, snapshots[0], keys, pkcsVerifier); !errors.As(err, &hashErr) {
+		t.Fatalf("expected HashErr for unknown hash ID, got %v", err)
+	}
+}
+
+var testKeys = make(map[int]*rsa.PrivateKey)
+
+// testKey returns a cached RSA key so tests don't regenerate keys
+func testKey(t *testing.T, i int) *rsa.PrivateKey {
+	if key, ok := testKeys[i]; ok {
+		return key
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testKeys[i] = key
+	return key
+}
+
+//EPOCH BINDING
+func TestEpochSignatureBoundToTransaction(t *testing.T) {
+	key := testKey(t, 0)
+	keys := map[string]crypto.PublicKey{"0": &key.PublicKey}
+
+	txA := createTransaction(1, 1, 10, "ID1", "ID2")
+	txB := createTransaction(2, 1, 99, "ID1", "ID3")
+	snapshotA := NewSimpleSnapshot(txA)
+	snapshotB := NewSimpleSnapshot(txB)
+
+	proofA, err := NewSimpleProofTuple(txA, "0", 4, 20, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proofB, err := NewSimpleProofTuple(txB, "0", 4, 20, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same node, epoch and balance but the epoch signature comes from snapshot A
+	proofB.protoProofTuple.EpochSign = proofA.protoProofTuple.EpochSign
+	snapshotB.AddProof(proofB)
+	if err := VerifySnapshot(1, snapshotB, keys, pkcsVerifier); err == nil {
+		t.Fatal("epoch signature lifted from another snapshot was accepted")
+	}
+
+	triplet := proofA.GetEpoch()
+	sig, err := triplet.Sign(snapshotA, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := triplet.Verify(snapshotA, &key.PublicKey, sig, pkcsVerifier); err != nil {
+		t.Fatalf("epoch signature rejected for its own snapshot: %v", err)
+	}
+	if err := triplet.Verify(snapshotB, &key.PublicKey, sig, pkcsVerifier); err == nil {
+		t.Fatal("epoch signature accepted for another snapshot")
+	}
+}
+
+//NETWORK
+func TestNetworkReplay(t *testing.T) {
+	key := testKey(t, 0)
+	keys := map[string]crypto.PublicKey{"0": &key.PublicKey}
+
+	tx := createTransaction(1, 1, 10, "ID1", "ID2")
+	tx.SetNetwork("staging")
+	snapshot := NewSimpleSnapshot(tx)
+	tup, err := NewSimpleProofTuple(tx, "0", 1, 5, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot.AddProof(tup)
+
+	staging := &SnapshotVerifier{Pass: 1, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier, Network: "staging"}
+	if err := staging.Verify(snapshot); err != nil {
+		t.Fatalf("snapshot rejected on its own network: %v", err)
+	}
+
+	var netErr *NetworkErr
+	production := &SnapshotVerifier{Pass: 1, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier, Network: "production"}
+	if err := production.Verify(snapshot); !errors.As(err, &netErr) {
+		t.Fatalf("expected NetworkErr on another network, got %v", err)
+	}
+	if err := VerifySnapshot(1, snapshot, keys, pkcsVerifier); !errors.As(err, &netErr) {
+		t.Fatalf("expected NetworkErr without a configured network, got %v", err)
+	}
+
+	// Relabeling the snapshot breaks the signatures
+	tx.SetNetwork("production")
+	snapshot.protoSnapshot.Network = "production"
+	if err := production.Verify(snapshot); err == nil {
+		t.Fatal("relabeled snapshot verified on another network")
+	}
+}
+
+func TestTransactionIds(t *testing.T) {
+	key := testKey(t, 0)
+	keys := map[string]crypto.PublicKey{"0": &key.PublicKey}
+	proof := testProof{"0", 1, 5, key}
+
+	tx := createTransaction(1, 1, 10, "ID1", "ID2")
+	if err := tx.AssignId(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.CheckId(); err != nil {
+		t.Fatalf("assigned id rejected: %v", err)
+	}
+	if err := VerifySnapshot(1, createSnapshot(t, tx, proof), keys, pkcsVerifier); err != nil {
+		t.Fatalf("snapshot with a valid id rejected: %v", err)
+	}
+	sha512Id, err := tx.ComputeId(crypto.SHA512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.SetId(sha512Id)
+	if err := tx.CheckId(); err != nil {
+		t.Fatalf("SHA-512 id rejected: %v", err)
+	}
+
+	// An id that doesn't match the content fails even with valid signatures
+	var idErr *TransactionIdErr
+	stale := createTransaction(1, 1, 10, "ID1", "ID2")
+	if err := stale.AssignId(); err != nil {
+		t.Fatal(err)
+	}
+	stale.SetValueExchange(11)
+	if err := stale.CheckId(); !errors.As(err, &idErr) {
+		t.Fatalf("expected TransactionIdErr for changed content, got %v", err)
+	}
+	if err := VerifySnapshot(1, createSnapshot(t, stale, proof), keys, pkcsVerifier); !errors.As(err, &idErr) {
+		t.Fatalf("expected TransactionIdErr from VerifySnapshot, got %v", err)
+	}
+	for _, id := range []string{"tx-1", ":00", "md5:00"} {
+		stale.SetId(id)
+		if err := stale.CheckId(); !errors.As(err, &idErr) {
+			t.Errorf("expected TransactionIdErr for %q, got %v", id, err)
+		}
+	}
+
+	// Transactions without an id are only rejected when ids are required
+	unnamed := createSnapshot(t, createTransaction(1, 1, 10, "ID1", "ID2"), proof)
+	if err := VerifySnapshot(1, unnamed, keys, pkcsVerifier); err != nil {
+		t.Fatalf("snapshot without an id rejected: %v", err)
+	}
+	sv := &SnapshotVerifier{Pass: 1, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier, RequireTransactionId: true}
+	if err := sv.Verify(unnamed); !errors.As(err, &idErr) {
+		t.Fatalf("expected TransactionIdErr when ids are required, got %v", err)
+	}
+}
+
+//REPORT
+func TestVerificationReport(t *testing.T) {
+	tx := createTransaction(1, 1, 10, "ID1", "ID2")
+	snapshot := NewSimpleSnapshot(tx)
+	keys := make(map[string]crypto.PublicKey)
+	for i := 0; i < 3; i++ {
+		key := testKey(t, i)
+		id := strconv.Itoa(i)
+		tup, err := NewSimpleProofTuple(tx, id, 1, 5, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 {
+			tup.protoProofTuple.EpochSign = nil
+		}
+		keys[id] = &key.PublicKey
+		if i == 2 {
+			keys[id] = &testKey(t, 0).PublicKey
+		}
+		snapshot.AddProof(tup)
+	}
+
+	sv := &SnapshotVerifier{Pass: 0.5, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier}
+	report := sv.Report(snapshot)
+	if report.Err == nil {
+		t.Fatal("snapshot with one valid proof out of three passed")
+	}
+	if report.Passed != 1 || report.Total != 3 || len(report.Proofs) != 3 {
+		t.Fatalf("unexpected tally %d/%d", report.Passed, report.Total)
+	}
+
+	valid, badEpoch := report.Proofs[0], report.Proofs[1]
+	if !valid.Valid() || valid.NodeId != "0" || !valid.KeyFound {
+		t.Fatalf("expected proof 0 to be valid: %+v", valid)
+	}
+	if badEpoch.TransactionErr != nil || badEpoch.EpochErr == nil {
+		t.Fatalf("expected only the epoch signature of proof 1 to fail: %+v", badEpoch)
+	}
+	wrongKey := report.Failed()[1]
+	if wrongKey.NodeId != "2" || wrongKey.TransactionErr == nil || wrongKey.EpochErr == nil {
+		t.Fatalf("expected both signatures of proof 2 to fail: %+v", wrongKey)
+	}
+}
+
+//PARALLEL
+func TestVerifyContextMatchesSequential(t *testing.T) {
+	totalKeys := 6
+	keys := make(map[string]crypto.PublicKey)
+	for i := 0; i < totalKeys; i++ {
+		keys[strconv.Itoa(i)] = &testKey(t, i).PublicKey
+	}
+
+	for pattern := 0; pattern < 1<<totalKeys; pattern += 5 {
+		tx := createTransaction(int32(pattern), 1, 2, "ID1", "ID2")
+		snapshot := NewSimpleSnapshot(tx)
+		for i := 0; i < totalKeys; i++ {
+			tup, err := NewSimpleProofTuple(tx, strconv.Itoa(i), 1, 5, testKey(t, i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pattern&(1<<i) != 0 {
+				tup.protoProofTuple.TransactionSign = nil
+			}
+			snapshot.AddProof(tup)
+		}
+
+		for _, pass := range []float64{0.3, 0.5, 0.666, 1} {
+			sv := &SnapshotVerifier{Pass: pass, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier, Workers: 3}
+			seqErr := sv.Verify(snapshot)
+			parErr := sv.VerifyContext(context.Background(), snapshot)
+			if reflect.TypeOf(seqErr) != reflect.TypeOf(parErr) {
+				t.Fatalf("pattern %b pass %v: sequential %v, parallel %v", pattern, pass, seqErr, parErr)
+			}
+		}
+	}
+}
+
+func TestVerifyContextCancel(t *testing.T) {
+	key := testKey(t, 0)
+	tx := createTransaction(1, 1, 2, "ID1", "ID2")
+	snapshot := NewSimpleSnapshot(tx)
+	keys := make(map[string]crypto.PublicKey)
+	for i := 0; i < 4; i++ {
+		id := strconv.Itoa(i)
+		tup, err := NewSimpleProofTuple(tx, id, 1, 5, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot.AddProof(tup)
+		keys[id] = &key.PublicKey
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sv := &SnapshotVerifier{Pass: 1, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier}
+	if err := sv.VerifyContext(ctx, snapshot); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+//POLICY
+func TestVerificationPolicy(t *testing.T) {
+	tx := createTransaction(1, 1, 10, "g", "l")
+	tx.SetBystanders([]string{"b1", "b2", "b3"})
+	snapshot := NewSimpleSnapshot(tx)
+	keys := make(map[string]crypto.PublicKey)
+	for i, id := range []string{"g", "b1", "b2", "x"} {
+		key := testKey(t, i)
+		keys[id] = &key.PublicKey
+		tup, err := NewSimpleProofTuple(tx, id, 1, 5, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot.AddProof(tup)
+	}
+
+	tests := []struct {
+		policy VerificationPolicy
+		pass   bool
+	}{
+		{VerificationPolicy{RequireGainer: true}, true},
+		{VerificationPolicy{RequireGainer: true, RequireLoser: true}, false},
+		{VerificationPolicy{BystanderQuorum: 0.6}, true},
+		{VerificationPolicy{BystanderQuorum: 0.7}, false},
+		{VerificationPolicy{NonParticipants: IgnoreNonParticipants}, true},
+		{VerificationPolicy{NonParticipants: RejectNonParticipants}, false},
+	}
+	for i, test := range tests {
+		policy := test.policy
+		sv := &SnapshotVerifier{Keys: MapKeyResolver(keys), Verifier: pkcsVerifier, Policy: &policy}
+		report := sv.Report(snapshot)
+		if (report.Err == nil) != test.pass {
+			t.Fatalf("policy %d: expected pass %v, got %v", i, test.pass, report.Err)
+		}
+		if parErr := sv.VerifyContext(context.Background(), snapshot); reflect.TypeOf(parErr) != reflect.TypeOf(report.Err) {
+			t.Fatalf("policy %d: parallel result %v differs from %v", i, parErr, report.Err)
+		}
+		var policyErr *PolicyErr
+		if !test.pass && !errors.As(report.Err, &policyErr) {
+			t.Fatalf("policy %d: expected PolicyErr, got %v", i, report.Err)
+		}
+		if policy.NonParticipants == IgnoreNonParticipants && !report.Proofs[3].Ignored {
+			t.Fatalf("policy %d: proof from non-participant was not ignored", i)
+		}
+	}
+
+	// Stopping early once the gainer can't pass still reports the policy
+	badGainer := createSnapshot(t, tx, testProof{"g", 1, 5, testKey(t, 0)}, testProof{"b1", 1, 5, testKey(t, 1)},
+		testProof{"b2", 1, 5, testKey(t, 2)})
+	badGainer.protoSnapshot.Proofs[0].TransactionSign = nil
+	sv := &SnapshotVerifier{Pass: 0.5, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier, Workers: 1,
+		Policy: &VerificationPolicy{RequireGainer: true}}
+	var policyErr *PolicyErr
+	if err := sv.Verify(badGainer); !errors.As(err, &policyErr) {
+		t.Fatalf("expected PolicyErr, got %v", err)
+	}
+	if err := sv.VerifyContext(context.Background(), badGainer); !errors.As(err, &policyErr) {
+		t.Fatalf("expected PolicyErr from VerifyContext, got %v", err)
+	}
+
+	if report := (&SnapshotVerifier{Keys: MapKeyResolver(keys), Verifier: pkcsVerifier}).Report(snapshot); report.Proofs[0].Role != GainerRole ||
+		report.Proofs[1].Role != BystanderRole || report.Proofs[3].Role != NonParticipant {
+		t.Fatalf("unexpected roles in %+v", report.Proofs)
+	}
+}
+
+//WEIGHT
+func TestStakeWeightedQuorum(t *testing.T) {
+	tx := createTransaction(1, 1, 10, "ID1", "ID2")
+	balances := map[string]float64{"0": 100, "1": 10, "2": 10}
+	keys := make(map[string]crypto.PublicKey)
+	whale := NewSimpleSnapshot(tx)
+	minnows := NewSimpleSnapshot(tx)
+	for i := 0; i < 3; i++ {
+		id := strconv.Itoa(i)
+		key := testKey(t, i)
+		keys[id] = &key.PublicKey
+		for _, snapshot := range []*SimpleSnapshot{whale, minnows} {
+			tup, err := NewSimpleProofTuple(tx, id, 1, balances[id], key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Only the whale's proof is valid in one snapshot, only the minnows' in the other
+			if (snapshot == whale) != (i == 0) {
+				tup.protoProofTuple.TransactionSign = nil
+			}
+			snapshot.AddProof(tup)
+		}
+	}
+
+	heads := &SnapshotVerifier{Pass: 0.5, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier}
+	attested := &SnapshotVerifier{Pass: 0.5, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier, Weight: AttestedBalanceWeight}
+	known := &SnapshotVerifier{Pass: 0.5, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier, Weight: KnownBalanceWeight(balances)}
+
+	if heads.Verify(whale) == nil || heads.Verify(minnows) != nil {
+		t.Fatal("unweighted quorum should count heads")
+	}
+	// A forged proof can't inflate an honest node's attested weight
+	forged := createSnapshot(t, tx, testProof{"1", 1, 1e12, testKey(t, 5)})
+	whale.protoSnapshot.Proofs = append(whale.protoSnapshot.Proofs, forged.protoSnapshot.Proofs...)
+
+	for _, sv := range []*SnapshotVerifier{attested, known} {
+		report := sv.Report(whale)
+		if report.Err != nil || report.PassedWeight != 100 || report.TotalWeight != 120 {
+			t.Fatalf("weighted quorum rejected the majority stake: %+v", report)
+		}
+		if sv.Verify(minnows) == nil {
+			t.Fatal("weighted quorum accepted the minority stake")
+		}
+		if sv.VerifyContext(context.Background(), minnows) == nil {
+			t.Fatal("parallel weighted quorum accepted the minority stake")
+		}
+	}
+
+	// Known weights of nodes whose proofs fail still count against the quorum
+	forgedWhale := createSnapshot(t, tx, testProof{"A", 1, 10, testKey(t, 0)}, testProof{"B", 1, 990, testKey(t, 1)})
+	sv := &SnapshotVerifier{Pass: 0.5, Keys: MapKeyResolver{"A": &testKey(t, 0).PublicKey, "B": &testKey(t, 2).PublicKey},
+		Verifier: pkcsVerifier, Weight: KnownBalanceWeight(map[string]float64{"A": 10, "B": 990})}
+	var passErr *PassErr
+	if report := sv.Report(forgedWhale); !errors.As(report.Err, &passErr) || report.TotalWeight != 1000 || report.Ratio != 0.01 {
+		t.Fatalf("failed node dropped out of the known weight: %+v", report)
+	}
+	if err := sv.VerifyContext(context.Background(), forgedWhale); !errors.As(err, &passErr) {
+		t.Fatalf("expected PassErr from VerifyContext, got %v", err)
+	}
+}
+
+//DUPLICATES
+func TestDuplicateProofs(t *testing.T) {
+	tx := createTransaction(1, 1, 10, "ID1", "ID2")
+	keys := make(map[string]crypto.PublicKey)
+	proofs := make([]*SimpleProofTuple, 0, 10)
+	for i := 0; i < 10; i++ {
+		id := strconv.Itoa(i)
+		keys[id] = &testKey(t, i%3).PublicKey
+		tup, err := NewSimpleProofTuple(tx, id, 1, 5, testKey(t, i%3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i != 0 {
+			tup.protoProofTuple.TransactionSign = nil
+		}
+		proofs = append(proofs, tup)
+	}
+
+	snapshot := NewSimpleSnapshot(tx)
+	for _, proof := range proofs {
+		if err := snapshot.AddProof(proof); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var dupErr *DuplicateProofErr
+	if err := snapshot.AddProof(proofs[0]); !errors.As(err, &dupErr) {
+		t.Fatalf("expected DuplicateProofErr, got %v", err)
+	}
+	conflicting, err := NewSimpleProofTuple(tx, "0", 2, 5, testKey(t, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conflictErr *ConflictingProofErr
+	if err := snapshot.AddProof(conflicting); !errors.As(err, &conflictErr) || conflictErr.Conflict.Second != conflicting {
+		t.Fatalf("expected ConflictingProofErr, got %v", err)
+	}
+
+	// Pad the snapshot with copies of the only valid proof behind AddProof's back
+	for i := 0; i < 9; i++ {
+		snapshot.protoSnapshot.Proofs = append(snapshot.protoSnapshot.Proofs, proofs[0].protoProofTuple)
+	}
+	sv := &SnapshotVerifier{Pass: 0.5, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier}
+	report := sv.Report(snapshot)
+	if report.Err == nil || report.Duplicates != 9 || report.Ratio != 0.1 {
+		t.Fatalf("padded snapshot was not deduplicated: %+v", report)
+	}
+	if sv.VerifyContext(context.Background(), snapshot) == nil {
+		t.Fatal("padded snapshot passed parallel verification")
+	}
+
+	// A second valid proof from node 0 is a conflict and node 0 no longer counts
+	snapshot.protoSnapshot.Proofs = append(snapshot.protoSnapshot.Proofs, conflicting.protoProofTuple)
+	sv.Pass = 0.1
+	report = sv.Report(snapshot)
+	if report.Err == nil || len(report.Conflicts) != 1 || report.Conflicts[0].NodeId != "0" {
+		t.Fatalf("conflicting proofs were not reported: %+v", report)
+	}
+
+	// A forged copy can't knock out the honest proof it imitates
+	forged := NewSimpleSnapshot(tx)
+	fake := &SimpleProofTuple{protoProofTuple: &Snapshot_ProofTuple{Epoch: proofs[0].protoProofTuple.Epoch}}
+	forged.AddProof(fake)
+	forged.protoSnapshot.Proofs = append(forged.protoSnapshot.Proofs, proofs[0].protoProofTuple)
+	if report := sv.Report(forged); report.Err != nil || len(report.Conflicts) != 0 {
+		t.Fatalf("forged copy knocked out an honest proof: %+v", report)
+	}
+
+	// Signing the same statement twice is a duplicate, not a conflict
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resigned := createSnapshot(t, tx, testProof{"0", 1, 5, ecKey})
+	again := createSnapshot(t, tx, testProof{"0", 1, 5, ecKey})
+	resigned.protoSnapshot.Proofs = append(resigned.protoSnapshot.Proofs, again.protoSnapshot.Proofs...)
+	sv.Keys = MapKeyResolver{"0": &ecKey.PublicKey}
+	for _, report := range []*VerificationReport{sv.Report(resigned), sv.ReportContext(context.Background(), resigned)} {
+		if report.Err != nil || len(report.Conflicts) != 0 || report.Passed != 1 || report.Duplicates != 1 {
+			t.Fatalf("re-signed statement reported as a conflict: %+v", report)
+		}
+	}
+	// A copy with s flipped to n-s no longer verifies
+	malleated := createSnapshot(t, tx, testProof{"0", 1, 5, ecKey})
+	for _, sig := range []*[]byte{&malleated.protoSnapshot.Proofs[0].TransactionSign, &malleated.protoSnapshot.Proofs[0].EpochSign} {
+		parsed, err := parseECDSASignature(*sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed.S.Sub(elliptic.P256().Params().N, parsed.S)
+		if *sig, err = asn1.Marshal(*parsed); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resigned.protoSnapshot.Proofs = append(resigned.protoSnapshot.Proofs, malleated.protoSnapshot.Proofs...)
+	if report := sv.Report(resigned); report.Err != nil || len(report.Conflicts) != 0 || report.Proofs[2].Valid() {
+		t.Fatalf("malleated copy was accepted: %+v", report)
+	}
+}
+
+//EDGE CASES
+func TestNoProofsAndUnknownSigners(t *testing.T) {
+	tx := createTransaction(1, 1, 10, "ID1", "ID2")
+	empty := NewSimpleSnapshot(tx)
+	var noProofs *NoProofsErr
+	if err := VerifySnapshot(0, empty, nil, pkcsVerifier); !errors.As(err, &noProofs) {
+		t.Fatalf("expected NoProofsErr, got %v", err)
+	}
+	sv := &SnapshotVerifier{Verifier: pkcsVerifier}
+	if err := sv.VerifyContext(context.Background(), empty); !errors.As(err, &noProofs) {
+		t.Fatalf("expected NoProofsErr from VerifyContext, got %v", err)
+	}
+	if report := sv.Report(empty); report.Ratio != 0 {
+		t.Fatalf("expected a ratio of 0 without proofs, got %v", report.Ratio)
+	}
+
+	snapshot := NewSimpleSnapshot(tx)
+	for i := 0; i < 3; i++ {
+		tup, err := NewSimpleProofTuple(tx, strconv.Itoa(i), 1, 5, testKey(t, i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Without a scheme the proofs go through pkcsVerifier
+		tup.protoProofTuple.Scheme = ""
+		snapshot.AddProof(tup)
+	}
+
+	weightless := &SnapshotVerifier{Verifier: pkcsVerifier, Weight: KnownBalanceWeight(nil)}
+	if report := weightless.Report(snapshot); report.Ratio != 0 {
+		t.Fatalf("expected a ratio of 0 without any weight, got %v", report.Ratio)
+	}
+
+	// pkcsVerifier panics on nil keys so it must never see one
+	calls := 0
+	countingVerifier := func(key crypto.PublicKey, hash crypto.Hash, digest []byte, sig []byte) error {
+		calls++
+		return pkcsVerifier(key, hash, digest, sig)
+	}
+	keys := map[string]crypto.PublicKey{"1": (*rsa.PublicKey)(nil), "2": &testKey(t, 2).PublicKey}
+	report := (&SnapshotVerifier{Keys: MapKeyResolver(keys), Verifier: countingVerifier}).Report(snapshot)
+	if !report.Proofs[2].Valid() || calls != 2 {
+		t.Fatalf("expected pkcsVerifier to check both signatures of node 2, got %d calls: %+v", calls, report.Proofs[2])
+	}
+	var unknown *UnknownSignerErr
+	for _, result := range report.Proofs[:2] {
+		if result.KeyFound || !errors.As(result.TransactionErr, &unknown) || !errors.As(result.EpochErr, &unknown) {
+			t.Fatalf("expected UnknownSignerErr for node %s: %+v", result.NodeId, result)
+		}
+	}
+	if err := snapshot.GetProofs()[0].GetEpoch().Verify(snapshot, nil, nil, pkcsVerifier); !errors.As(err, &unknown) {
+		t.Fatalf("expected UnknownSignerErr from SimpleEpochTriplet.Verify, got %v", err)
+	}
+}
+
+//SIGNATURE ENCODING
+func TestProofSignatureEncoding(t *testing.T) {
+	key := testKey(t, 0)
+	keys := map[string]crypto.PublicKey{"0": &key.PublicKey}
+	tx := createTransaction(1, 1, 10, "ID1", "ID2")
+	tup, err := NewSimpleProofTuple(tx, "0", 1, 5, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Proofs written before the bytes fields held base64 text
+	old := &SimpleProofTuple{protoProofTuple: proto.Clone(tup.protoProofTuple).(*Snapshot_ProofTuple)}
+	old.protoProofTuple.Version = ProofVersionBase64
+	old.protoProofTuple.TransactionSign = []byte(base64.StdEncoding.EncodeToString(tup.protoProofTuple.TransactionSign))
+	old.protoProofTuple.EpochSign = []byte(base64.StdEncoding.EncodeToString(tup.protoProofTuple.EpochSign))
+	oldRaw, _ := proto.Marshal(old.protoProofTuple)
+	newRaw, _ := proto.Marshal(tup.protoProofTuple)
+	if len(newRaw) >= len(oldRaw) {
+		t.Fatalf("raw proof is %d bytes, base64 proof %d", len(newRaw), len(oldRaw))
+	}
+	if !old.Equal(tup) {
+		t.Fatal("the same signatures in different encodings are not equal")
+	}
+
+	snapshot := NewSimpleSnapshot(tx)
+	snapshot.AddProof(old)
+	var dup *DuplicateProofErr
+	if err := snapshot.AddProof(tup); !errors.As(err, &dup) {
+		t.Fatalf("expected DuplicateProofErr, got %v", err)
+	}
+	if err := VerifySnapshot(1, snapshot, keys, pkcsVerifier); err != nil {
+		t.Fatalf("base64 proof failed: %v", err)
+	}
+	if err := old.Upgrade(); err != nil || old.GetVersion() != ProofVersionRaw {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	if err := VerifySnapshot(1, snapshot, keys, pkcsVerifier); err != nil {
+		t.Fatalf("upgraded proof failed: %v", err)
+	}
+
+	var malformed *MalformedSignatureErr
+	old.protoProofTuple.EpochSign = []byte("not base64!")
+	old.protoProofTuple.Version = ProofVersionBase64
+	report := (&SnapshotVerifier{Pass: 1, Keys: MapKeyResolver(keys), Verifier: pkcsVerifier}).Report(snapshot)
+	if !errors.As(report.Proofs[0].EpochErr, &malformed) {
+		t.Fatalf("expected MalformedSignatureErr, got %v", report.Proofs[0].EpochErr)
+	}
+}
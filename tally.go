@@ -0,0 +1,249 @@
+package snapshot
+
+/* roleTally counts the nodes of one role */
+type roleTally struct {
+	passed  int
+	pending int
+}
+
+/* weightTally sums the weights of a group of nodes */
+type weightTally struct {
+	passed  float64
+	pending float64
+}
+
+/* nodeTally tracks every proof a single node submitted. A node only
+counts as valid if all of its valid proofs sign the same statement, valid
+proofs of different statements are a conflict and count as a failure */
+type nodeTally struct {
+	role ProofRole
+	// pending is the number of proofs not checked yet
+	pending int
+	/* weight is the largest weight claimed by the node's proofs. It
+	bounds the node's weight until all of them are checked */
+	weight float64
+	/* attested is the largest weight among the proofs whose epoch
+	signature verified. A forged proof can't raise it */
+	attested float64
+	// valid is the number of different statements signed by valid proofs
+	valid       int
+	validWeight float64
+	// statement is the statement of the first valid proof
+	statement proofStatement
+}
+
+/* tally counts proof results and decides whether a snapshot passes.
+Results are grouped by Node Id so a node counts once no matter how many
+proofs it has in the snapshot. The tally knows every proof up front so
+it can tell as soon as the outcome can no longer change. When weights
+are attested by the proofs themselves a node weighs what its valid
+epoch signatures attest to, so until its proofs are checked its claimed
+weight is counted as the most it could weigh */
+type tally struct {
+	pass     float64
+	policy   *VerificationPolicy
+	attested bool
+
+	nodes      map[string]*nodeTally
+	unresolved int
+
+	// counted holds the weight of every node that isn't ignored
+	counted weightTally
+	// total is exact once every node is resolved and an upper bound before
+	total float64
+
+	gainer         roleTally
+	loser          roleTally
+	bystanders     roleTally
+	bystanderTotal int
+
+	// rejectErr is set if a proof breaks the policy outright
+	rejectErr error
+}
+
+/* newTally returns a tally for the given proof results. The results
+only need their NodeId, Role, Weight, Ignored and Duplicate fields
+filled in. If attested is set the weights come from the proofs and only
+count once an epoch signature vouches for them */
+func newTally(pass float64, policy *VerificationPolicy, attested bool, tx *SimpleTransaction,
+	results []ProofResult) *tally {
+	t := &tally{
+		pass:           pass,
+		policy:         policy,
+		attested:       attested,
+		nodes:          make(map[string]*nodeTally),
+		bystanderTotal: len(tx.GetBystanders()),
+	}
+	for _, result := range results {
+		if !result.counted() {
+			continue
+		}
+		node, ok := t.nodes[result.NodeId]
+		if !ok {
+			node = &nodeTally{role: result.Role}
+			t.nodes[result.NodeId] = node
+		}
+		node.pending++
+		if result.Weight > node.weight {
+			node.weight = result.Weight
+		}
+		if policy != nil && policy.NonParticipants == RejectNonParticipants &&
+			result.Role == NonParticipant && t.rejectErr == nil {
+			t.rejectErr = policyErr("proof from non-participant %q", result.NodeId)
+		}
+	}
+
+	for _, node := range t.nodes {
+		t.unresolved++
+		t.total += node.weight
+		t.counted.pending += node.weight
+		if group := t.group(node.role); group != nil {
+			group.pending++
+		}
+	}
+	return t
+}
+
+// group returns the roleTally for a role or nil if it isn't tracked
+func (t *tally) group(role ProofRole) *roleTally {
+	switch role {
+	case GainerRole:
+		return &t.gainer
+	case LoserRole:
+		return &t.loser
+	case BystanderRole:
+		return &t.bystanders
+	}
+	return nil
+}
+
+// add records the result of a single proof
+func (t *tally) add(result ProofResult) {
+	if !result.counted() {
+		return
+	}
+	node := t.nodes[result.NodeId]
+	node.pending--
+	if result.Valid() && (node.valid == 0 || result.statement != node.statement) {
+		if node.valid == 0 {
+			node.statement, node.validWeight = result.statement, result.Weight
+		}
+		node.valid++
+	}
+	if result.EpochErr == nil && result.Weight > node.attested {
+		node.attested = result.Weight
+	}
+	if node.pending == 0 {
+		t.resolve(node)
+	}
+}
+
+/* resolve moves a node whose proofs have all been checked from pending
+to its final state */
+func (t *tally) resolve(node *nodeTally) {
+	t.unresolved--
+	t.counted.pending -= node.weight
+	if t.attested {
+		t.total += node.attested - node.weight
+	}
+	group := t.group(node.role)
+	if group != nil {
+		group.pending--
+	}
+	if node.valid != 1 {
+		return
+	}
+	t.counted.passed += node.validWeight
+	if group != nil {
+		group.passed++
+	}
+}
+
+/* requirement is the state of a single condition a snapshot must meet.
+met is true once it holds, possible is false once it never can */
+type requirement struct {
+	met      bool
+	possible bool
+	err      error
+}
+
+// requirements returns the state of every condition the snapshot must meet
+func (t *tally) requirements() []requirement {
+	reqs := make([]requirement, 0, 5)
+	if len(t.nodes) == 0 {
+		reqs = append(reqs, requirement{err: &NoProofsErr{simpleErr{err: nil, msg: "Snapshot holds no proofs to verify"}}})
+	}
+	reqs = append(reqs, requirement{
+		met:      didPass(t.pass, t.counted.passed, t.total) == nil,
+		possible: didPass(t.pass, t.counted.passed+t.counted.pending, t.total) == nil,
+		err:      didPass(t.pass, t.counted.passed, t.total),
+	})
+	if t.rejectErr != nil {
+		reqs = append(reqs, requirement{err: t.rejectErr})
+	}
+	if t.policy == nil {
+		return reqs
+	}
+
+	if t.policy.RequireGainer {
+		reqs = append(reqs, requirement{
+			met:      t.gainer.passed > 0,
+			possible: t.gainer.passed+t.gainer.pending > 0,
+			err:      policyErr("no valid proof from the gaining party"),
+		})
+	}
+	if t.policy.RequireLoser {
+		reqs = append(reqs, requirement{
+			met:      t.loser.passed > 0,
+			possible: t.loser.passed+t.loser.pending > 0,
+			err:      policyErr("no valid proof from the losing party"),
+		})
+	}
+	if t.policy.BystanderQuorum > 0 {
+		reqs = append(reqs, requirement{
+			met:      t.quorumMet(t.bystanders.passed),
+			possible: t.quorumMet(t.bystanders.passed + t.bystanders.pending),
+			err: policyErr("%d of %d bystanders proved, need %v", t.bystanders.passed,
+				t.bystanderTotal, t.policy.BystanderQuorum),
+		})
+	}
+	return reqs
+}
+
+/* quorumMet returns whether n valid bystander proofs meet the bystander
+quorum. A transaction without bystanders always meets it */
+func (t *tally) quorumMet(n int) bool {
+	if t.bystanderTotal == 0 {
+		return true
+	}
+	return float64(n)/float64(t.bystanderTotal) >= t.policy.BystanderQuorum
+}
+
+/* decided returns whether the outcome is already fixed, either because
+every requirement is met or because the first requirement that isn't met
+never can be. Requirements only ever go from possible to met or to
+impossible, so err then returns what it returns once every node is
+resolved */
+func (t *tally) decided() bool {
+	if t.unresolved == 0 {
+		return true
+	}
+	for _, req := range t.requirements() {
+		if !req.met {
+			return !req.possible
+		}
+	}
+	return true
+}
+
+/* err returns nil if the recorded results meet every requirement and
+otherwise the error of the first one that isn't met. Nodes that were
+never resolved count as failures */
+func (t *tally) err() error {
+	for _, req := range t.requirements() {
+		if !req.met {
+			return req.err
+		}
+	}
+	return nil
+}
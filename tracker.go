@@ -0,0 +1,128 @@
+package snapshot
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+/* EpochTracker follows the epoch of every node across snapshots. Each
+snapshot a node proves must carry the epoch right after the last one it
+proved, so skipped, repeated and regressed epochs are caught. A node's
+first snapshot sets its starting epoch. It is safe for concurrent use */
+type EpochTracker struct {
+	mutex  sync.Mutex
+	epochs map[string]int32
+}
+
+// NewEpochTracker returns an EpochTracker that hasn't seen any node yet
+func NewEpochTracker() *EpochTracker {
+	return &EpochTracker{epochs: make(map[string]int32)}
+}
+
+/* Observe records the epochs proven by the valid proofs of a snapshot
+according to report, the outcome of verifying it. Proofs that failed or
+weren't checked are left out so a forged proof can't move a node's
+epoch. Snapshots must be observed in the order they were made. If the
+snapshot failed verification its error is returned, if report belongs
+to another snapshot a ReportErr and if any proving node's epoch doesn't
+follow its last one an EpochErr. In all cases nothing is recorded */
+func (et *EpochTracker) Observe(snapshot *SimpleSnapshot, report *VerificationReport) error {
+	proven, err := report.validEpochs(snapshot)
+	if err != nil {
+		return err
+	}
+	return et.advance(proven)
+}
+
+/* advance records the epochs proven by a single snapshot. An EpochErr
+is returned and nothing recorded if any node's epoch doesn't follow its
+last one */
+func (et *EpochTracker) advance(proven []*SimpleEpochTriplet) error {
+	et.mutex.Lock()
+	defer et.mutex.Unlock()
+
+	next := make(map[string]int32)
+	for _, triplet := range proven {
+		id, epoch := triplet.GetId(), triplet.GetEpochNumber()
+		if seen, ok := next[id]; ok {
+			if seen != epoch {
+				return epochErr(id, seen, epoch)
+			}
+			continue
+		}
+		if last, ok := et.epochs[id]; ok {
+			if last == math.MaxInt32 || epoch != last+1 {
+				return epochErr(id, last+1, epoch)
+			}
+		}
+		next[id] = epoch
+	}
+
+	for id, epoch := range next {
+		et.epochs[id] = epoch
+	}
+	return nil
+}
+
+/* Epoch returns the last epoch node id proved and whether the node was
+seen at all */
+func (et *EpochTracker) Epoch(id string) (int32, bool) {
+	et.mutex.Lock()
+	defer et.mutex.Unlock()
+	epoch, ok := et.epochs[id]
+	return epoch, ok
+}
+
+/* Marshal serializes the state of the EpochTracker into a slice of
+bytes. Nodes are written in Node Id order so equal states serialize the
+same */
+func (et *EpochTracker) Marshal() ([]byte, error) {
+	et.mutex.Lock()
+	state := &EpochTrackerState{Nodes: make([]*EpochTrackerState_NodeEpoch, 0, len(et.epochs))}
+	for id, epoch := range et.epochs {
+		state.Nodes = append(state.Nodes, &EpochTrackerState_NodeEpoch{Id: id, Epoch: epoch})
+	}
+	et.mutex.Unlock()
+	sort.Slice(state.Nodes, func(i, j int) bool {
+		return state.Nodes[i].Id < state.Nodes[j].Id
+	})
+
+	out, err := proto.Marshal(state)
+	if err != nil {
+		return out, &MarshalErr{simpleErr{err: err, msg: "EpochTracker.Marshal()"}}
+	}
+	return out, nil
+}
+
+/* Unmarshal restores the state of the EpochTracker from a slice of
+bytes, replacing everything it has seen so far */
+func (et *EpochTracker) Unmarshal(serial []byte) error {
+	state := &EpochTrackerState{}
+	if err := proto.Unmarshal(serial, state); err != nil {
+		return &MarshalErr{simpleErr{err: err, msg: "EpochTracker.Unmarshal()"}}
+	}
+	epochs := make(map[string]int32, len(state.GetNodes()))
+	for _, node := range state.GetNodes() {
+		epochs[node.GetId()] = node.GetEpoch()
+	}
+
+	et.mutex.Lock()
+	defer et.mutex.Unlock()
+	et.epochs = epochs
+	return nil
+}
+
+// epochErr builds the error for node id proving epoch got instead of expected
+func epochErr(id string, expected int32, got int32) error {
+	return &EpochErr{
+		simpleErr: simpleErr{err: fmt.Errorf("%q proved epoch %d, expected %d", id, got, expected),
+			msg: "Epoch out of sequence"},
+		NodeId:   id,
+		Expected: expected,
+		Got:      got,
+	}
+}
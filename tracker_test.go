@@ -0,0 +1,88 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEpochTracker(t *testing.T) {
+	tx := createTransaction(1, 1, 10, "ID1", "ID2")
+	a, b, c := testKey(t, 0), testKey(t, 1), testKey(t, 2)
+	keys := MapKeyResolver{"a": &a.PublicKey, "b": &b.PublicKey, "c": &c.PublicKey}
+	sv := &SnapshotVerifier{Pass: 0.5, Keys: keys, Verifier: pkcsVerifier}
+
+	tracker := NewEpochTracker()
+	observe := func(snapshot *SimpleSnapshot) error {
+		return tracker.Observe(snapshot, sv.Report(snapshot))
+	}
+	if err := observe(createSnapshot(t, tx, testProof{"a", 1, 5, a}, testProof{"b", 7, 5, b})); err != nil {
+		t.Fatal(err)
+	}
+	if err := observe(createSnapshot(t, tx, testProof{"a", 2, 5, a}, testProof{"c", 3, 5, c})); err != nil {
+		t.Fatal(err)
+	}
+
+	var epochErr *EpochErr
+	for _, bad := range []struct {
+		name  string
+		proof testProof
+	}{
+		{"skipped", testProof{"a", 4, 5, a}},
+		{"repeated", testProof{"a", 2, 5, a}},
+		{"regressed", testProof{"b", 6, 5, b}},
+	} {
+		err := observe(createSnapshot(t, tx, bad.proof))
+		if !errors.As(err, &epochErr) {
+			t.Fatalf("%s: expected EpochErr, got %v", bad.name, err)
+		}
+	}
+	if epochErr.NodeId != "b" || epochErr.Expected != 8 || epochErr.Got != 6 {
+		t.Fatalf("unexpected error details %+v", epochErr)
+	}
+
+	// A rejected snapshot records nothing, not even its valid epochs
+	if err := observe(createSnapshot(t, tx, testProof{"c", 4, 5, c}, testProof{"b", 9, 5, b})); err == nil {
+		t.Fatal("skipped epoch was accepted")
+	}
+	if epoch, _ := tracker.Epoch("c"); epoch != 3 {
+		t.Fatalf("rejected snapshot moved c to epoch %d", epoch)
+	}
+
+	// A forged proof in a passing snapshot doesn't move its node
+	forged := createSnapshot(t, tx, testProof{"a", 3, 5, a}, testProof{"c", 7, 5, a})
+	if err := observe(forged); err != nil {
+		t.Fatalf("passing snapshot with a forged proof rejected: %v", err)
+	}
+	if epoch, _ := tracker.Epoch("c"); epoch != 3 {
+		t.Fatalf("forged proof moved c to epoch %d", epoch)
+	}
+	// A report of another snapshot can't vouch for a forged proof
+	var reportErr *ReportErr
+	honest := createSnapshot(t, tx, testProof{"a", 4, 5, a})
+	if err := tracker.Observe(createSnapshot(t, tx, testProof{"c", 4, 5, a}), sv.Report(honest)); !errors.As(err, &reportErr) {
+		t.Fatalf("expected ReportErr for a mismatched report, got %v", err)
+	}
+	failed := createSnapshot(t, tx, testProof{"a", 4, 5, a})
+	failed.protoSnapshot.Proofs[0].TransactionSign = nil
+	if err := observe(failed); err == nil {
+		t.Fatal("snapshot that failed verification was observed")
+	}
+
+	raw, err := tracker.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewEpochTracker()
+	if err := restored.Unmarshal(raw); err != nil {
+		t.Fatal(err)
+	}
+	for id, expected := range map[string]int32{"a": 3, "b": 7, "c": 3} {
+		if epoch, ok := restored.Epoch(id); !ok || epoch != expected {
+			t.Errorf("%s: restored epoch %d, expected %d", id, epoch, expected)
+		}
+	}
+	next := createSnapshot(t, tx, testProof{"a", 4, 5, a}, testProof{"b", 8, 5, b}, testProof{"c", 4, 5, c})
+	if err := restored.Observe(next, sv.Report(next)); err != nil {
+		t.Fatal(err)
+	}
+}
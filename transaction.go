@@ -1,6 +1,11 @@
 package snapshot
 
 import (
+	"crypto"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
 	"google.golang.org/protobuf/proto"
 )
 
@@ -37,6 +42,89 @@ func (st *SimpleTransaction) Unmarshal(serial []byte) error {
 	return nil
 }
 
+/* MarshalCanonical serializes the SimpleTransaction with the canonical
+signing encoding. This is the encoding that transaction digests are
+computed over */
+func (st *SimpleTransaction) MarshalCanonical() ([]byte, error) {
+	tx := st.protoTransaction
+	ce := &canonicalEncoder{}
+	ce.putString(1, tx.GetId())
+	ce.putInt32(2, tx.GetAction())
+	ce.putDouble(3, tx.GetReward())
+	ce.putDouble(4, tx.GetExchange())
+	ce.putString(5, tx.GetGainer())
+	ce.putString(6, tx.GetLoser())
+	ce.putStrings(7, tx.GetBystanders())
+	ce.putString(8, tx.GetNetwork())
+	return ce.bytes()
+}
+
+/* ComputeId returns the content address of the transaction, the hash ID
+followed by a colon and the hex encoded digest of its canonical encoding
+with the id left empty. The digest is taken under TransactionIdDomain so
+an id is never the same as the transaction's signing digest */
+func (st *SimpleTransaction) ComputeId(hash crypto.Hash) (string, error) {
+	hashID, err := HashID(hash)
+	if err != nil {
+		return "", err
+	}
+	content := &SimpleTransaction{protoTransaction: proto.Clone(st.protoTransaction).(*Transaction)}
+	content.protoTransaction.Id = ""
+	digest, err := digestMarshaler(content, TransactionIdDomain, hash)
+	if err != nil {
+		return "", &DigestErr{simpleErr{err: err, msg: "SimpleTransaction.ComputeId()"}}
+	}
+	return hashID + ":" + hex.EncodeToString(digest), nil
+}
+
+/* AssignId sets the id of the transaction to its content address using
+ProofHashFunc. It must be called again whenever the transaction changes */
+func (st *SimpleTransaction) AssignId() error {
+	id, err := st.ComputeId(ProofHashFunc)
+	if err != nil {
+		return err
+	}
+	st.protoTransaction.Id = id
+	return nil
+}
+
+/* CheckId returns nil if the id of the transaction is the content
+address of its current content. A TransactionIdErr is returned if it
+isn't */
+func (st *SimpleTransaction) CheckId() error {
+	id := st.GetId()
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) != 2 {
+		return transactionIdErr(id, fmt.Errorf("not a content address"))
+	}
+	if parts[0] == "" {
+		return transactionIdErr(id, fmt.Errorf("missing hash ID"))
+	}
+	hash, err := HashFromID(parts[0])
+	if err != nil {
+		return transactionIdErr(id, err)
+	}
+	expected, err := st.ComputeId(hash)
+	if err != nil {
+		return err
+	}
+	if id != expected {
+		return transactionIdErr(id, fmt.Errorf("content hashes to %q", expected))
+	}
+	return nil
+}
+
+// Getter for transaction ID
+func (st *SimpleTransaction) GetId() string {
+	return st.protoTransaction.GetId()
+}
+
+/* Setter for transaction ID. Use AssignId to set it to the content
+address of the transaction */
+func (st *SimpleTransaction) SetId(id string) {
+	st.protoTransaction.Id = id
+}
+
 // Getter for action code
 func (st *SimpleTransaction) GetActionCode() int32 {
 	return st.protoTransaction.GetAction()
@@ -96,3 +184,18 @@ func (st *SimpleTransaction) GetBystanders() []string {
 func (st *SimpleTransaction) SetBystanders(bystanders []string) {
 	st.protoTransaction.Bystanders = bystanders
 }
+
+// Getter for network ID
+func (st *SimpleTransaction) GetNetwork() string {
+	return st.protoTransaction.GetNetwork()
+}
+
+// Setter for network ID
+func (st *SimpleTransaction) SetNetwork(network string) {
+	st.protoTransaction.Network = network
+}
+
+// transactionIdErr builds a TransactionIdErr for the given id
+func transactionIdErr(id string, err error) error {
+	return &TransactionIdErr{simpleErr{err: err, msg: fmt.Sprintf("Transaction id %q", id)}}
+}
@@ -1,72 +1,455 @@
 package snapshot
 
 import (
+	"context"
 	"crypto"
-	_ "crypto/sha256"
-	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
 )
 
 /* Verifier is a function type that can verify data was signed by the
-owner of the provided public key */
+owner of the provided public key. The module never calls a Verifier
+with a missing key, those signatures fail with an UnknownSignerErr */
 type Verifier func(key crypto.PublicKey, hash crypto.Hash, digest []byte, sig []byte) error
 
+/* SnapshotVerifier holds the settings used to check a SimpleSnapshot.
+The zero value of every optional field keeps the default behavior */
+type SnapshotVerifier struct {
+	// Pass is the fraction of proofs, or of their weight, that must be valid
+	Pass float64
+	// Keys looks up the public keys proofs are checked with
+	Keys KeyResolver
+	/* Verifier checks the signatures of proofs that don't record a
+	signature scheme. Proofs that do are checked with the built-in verifier
+	of their scheme. Without it proofs lacking a scheme fail with a
+	SchemeErr. It must be safe for concurrent use when snapshots are
+	checked with VerifyContext */
+	Verifier Verifier
+
+	/* AllowedSchemes limits the signature schemes proofs may use. When it
+	is set proofs without a scheme, including legacy proofs, are rejected */
+	AllowedSchemes []string
+
+	/* Network is the ID of the network snapshots must belong to. The
+	empty string only accepts snapshots without a network ID */
+	Network string
+
+	/* SelfCertifying requires every Node Id to be the Fingerprint of the
+	key its proof is checked with. Proofs are checked with the public key
+	they embed, or the key from Keys if they embed none */
+	SelfCertifying bool
+
+	/* Certificates checks proofs with the key of the X.509 certificate
+	they carry or refer to instead of looking it up in Keys. It takes
+	precedence over SelfCertifying */
+	Certificates *CertificateVerifier
+
+	/* RequireTransactionId rejects snapshots whose transaction has no ID.
+	A transaction ID that doesn't match the transaction's content is always
+	rejected */
+	RequireTransactionId bool
+
+	/* AllowLegacy also accepts proofs signed over the truncated digest
+	produced by older releases. Only enable it to read existing archives */
+	AllowLegacy bool
+
+	/* Policy adds role based requirements such as mandatory gainer and
+	loser proofs. Without a policy every proof counts the same */
+	Policy *VerificationPolicy
+
+	/* Weight turns Pass into a stake weighted quorum where each proof
+	counts by its weight instead of once. Without it every proof weighs 1 */
+	Weight WeightFunc
+
+	/* Revocations distrusts the proofs of revoked nodes from the epoch
+	their key was revoked. The list's signature must be checked before it
+	is used here */
+	Revocations *SimpleRevocationList
+
+	/* Epochs holds the last epoch every node proved. A proof is checked
+	against Revocations at the epoch it claims or, if the node already
+	moved past that epoch, the node's next one, so a stolen key can't be
+	used by claiming an epoch from before its revocation. Without it the
+	claimed epoch is trusted. The verifier never updates it */
+	Epochs *EpochTracker
+
+	/* Replay rejects snapshots whose transaction the guard already
+	accepted with a ReplayErr. Snapshots are only recorded once they
+	verify */
+	Replay *ReplayGuard
+
+	/* Workers bounds the number of proofs checked at once by
+	VerifyContext. It defaults to GOMAXPROCS */
+	Workers int
+}
+
 /* VerifySnapshot returns whether or not the provided SimpleSnapshot is
 valid or not. If the percentage of valid SimpleProofTuples is greater
 than the pass parameter then VerifySnapshot returns nil, otherwise it
 returns an error */
 func VerifySnapshot(pass float64, snapshot *SimpleSnapshot, keys map[string]crypto.PublicKey,
 	verf Verifier) error {
-	tx := snapshot.GetTransaction()
-	tDigest, err := digestMarshaler(tx)
+	sv := &SnapshotVerifier{Pass: pass, Keys: MapKeyResolver(keys), Verifier: verf}
+	return sv.Verify(snapshot)
+}
+
+/* Verify returns nil if enough of the SimpleProofTuples held by the
+snapshot are valid to meet the Pass requirement */
+func (sv *SnapshotVerifier) Verify(snapshot *SimpleSnapshot) error {
+	return sv.Report(snapshot).Err
+}
+
+/* Report verifies the snapshot like Verify but also returns the outcome
+of every individual SimpleProofTuple */
+func (sv *SnapshotVerifier) Report(snapshot *SimpleSnapshot) *VerificationReport {
+	ctx := context.Background()
+	sc, err := sv.prepare(snapshot)
 	if err != nil {
-		return &DigestErr{simpleErr{err: err, msg: "VerifySnapshot()"}}
+		return &VerificationReport{Err: err}
 	}
 
-	totalPasses := 0
 	proofs := snapshot.GetProofs()
-	for _, proof := range proofs {
-		pk := keys[proof.GetEpoch().GetId()]
-		err := verifyProofComponents(proof, pk, verf, tDigest)
+	results := sv.classify(snapshot.GetTransaction(), proofs)
+	t := newTally(sv.Pass, sv.Policy, attestsWeight(sv.Weight), snapshot.GetTransaction(), results)
+	for i, proof := range proofs {
+		sv.checkProof(ctx, proof, sc, &results[i])
+		t.add(results[i])
+	}
+	return sv.accept(ctx, snapshot, newReport(proofs, results, t))
+}
+
+/* classify returns a ProofResult for every proof holding its Node Id and
+its role in the transaction. Proofs the policy ignores and identical
+copies of earlier proofs are marked */
+func (sv *SnapshotVerifier) classify(tx *SimpleTransaction, proofs []*SimpleProofTuple) []ProofResult {
+	results := make([]ProofResult, len(proofs))
+	seen := make(map[string][]*SimpleProofTuple)
+	for i, proof := range proofs {
+		id := proof.GetEpoch().GetId()
+		role := roleOf(tx, id)
+		results[i] = ProofResult{NodeId: id, Role: role, Weight: proofWeight(sv.Weight, proof.GetEpoch())}
+		if sv.Policy != nil && sv.Policy.NonParticipants == IgnoreNonParticipants && role == NonParticipant {
+			results[i].Ignored = true
+		}
+		for _, earlier := range seen[id] {
+			if proof.Equal(earlier) {
+				results[i].Duplicate = true
+				break
+			}
+		}
+		seen[id] = append(seen[id], proof)
+	}
+	return results
+}
+
+/* checkProof verifies a single SimpleProofTuple and records the outcome
+of each of its signatures in result. Proofs that aren't counted aren't
+checked and proofs made with a revoked key fail without being checked */
+func (sv *SnapshotVerifier) checkProof(ctx context.Context, proof *SimpleProofTuple, sc *snapshotContext,
+	result *ProofResult) {
+	if !result.counted() {
+		return
+	}
+	if sv.Revocations != nil {
+		epoch := sv.revocationEpoch(result.NodeId, proof.GetEpoch().GetEpochNumber())
+		if entry, revoked := sv.Revocations.Revoked(result.NodeId, epoch); revoked {
+			err := revokedSignerErr(entry)
+			result.TransactionErr, result.EpochErr, result.Revoked = err, err, true
+			return
+		}
+	}
+	pk, err := sv.proofKey(ctx, proof, result.NodeId)
+	if err != nil {
+		result.TransactionErr, result.EpochErr = err, err
+		return
+	}
+	result.KeyFound = true
+	verf, err := sv.proofVerifier(proof, pk)
+	if err != nil {
+		result.TransactionErr, result.EpochErr = err, err
+		return
+	}
+
+	result.TransactionErr, result.EpochErr = verifyProofComponents(proof, pk, verf, sc)
+	if !result.Valid() && sv.AllowLegacy && proof.GetScheme() == "" {
+		tErr, eErr := verifyLegacyProof(proof, pk, verf, sc)
+		if tErr == nil && eErr == nil {
+			result.TransactionErr, result.EpochErr, result.Legacy = nil, nil, true
+		}
+	}
+	if result.Valid() {
+		if result.statement, err = sc.statement(proof); err != nil {
+			result.EpochErr = &DigestErr{simpleErr{err: err, msg: "checkProof()"}}
+		}
+	}
+}
+
+/* revocationEpoch returns the epoch a proof of node id claiming epoch
+is checked against the revocation list at. Epochs the node already
+proved or moved past are replaced by the node's next epoch */
+func (sv *SnapshotVerifier) revocationEpoch(id string, claimed int32) int32 {
+	if sv.Epochs == nil {
+		return claimed
+	}
+	last, ok := sv.Epochs.Epoch(id)
+	if !ok || claimed > last {
+		return claimed
+	}
+	if last == math.MaxInt32 {
+		return last
+	}
+	return last + 1
+}
 
-		if err == nil {
-			totalPasses++
+/* proofKey returns the public key a proof is checked with. In
+certificate and self-certifying mode the key must belong to the proof's
+Node Id */
+func (sv *SnapshotVerifier) proofKey(ctx context.Context, proof *SimpleProofTuple, id string) (crypto.PublicKey, error) {
+	if sv.Certificates != nil {
+		return sv.Certificates.proofKey(ctx, proof, id)
+	}
+	if !sv.SelfCertifying {
+		return resolveKey(ctx, sv.Keys, id, proof.GetEpoch().GetEpochNumber())
+	}
+	pk, err := proof.GetPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	if pk == nil {
+		if pk, err = resolveKey(ctx, sv.Keys, id, proof.GetEpoch().GetEpochNumber()); err != nil {
+			return nil, err
 		}
 	}
-	return didPass(pass, totalPasses, len(proofs))
+	if err := CheckFingerprint(id, pk); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+/* proofVerifier returns the Verifier a proof is checked with. Proofs
+that record a signature scheme must use an allowed scheme that fits the
+signer's key, anything else is rejected with a SchemeErr so a proof
+can't pick a weaker algorithm than the key was issued for */
+func (sv *SnapshotVerifier) proofVerifier(proof *SimpleProofTuple, pk crypto.PublicKey) (Verifier, error) {
+	scheme := proof.GetScheme()
+	if len(sv.AllowedSchemes) > 0 {
+		allowed := false
+		for _, s := range sv.AllowedSchemes {
+			allowed = allowed || s == scheme
+		}
+		if !allowed {
+			return nil, schemeErr("signature scheme %q is not allowed", scheme)
+		}
+	}
+	if scheme == "" {
+		if sv.Verifier == nil {
+			return nil, schemeErr("proof records no signature scheme and no Verifier is set")
+		}
+		return sv.Verifier, nil
+	}
+	return schemeVerifier(scheme, pk)
+}
+
+/* accept records the transaction of a snapshot that verified with the
+replay guard and fails the report if it was accepted before */
+func (sv *SnapshotVerifier) accept(ctx context.Context, snapshot *SimpleSnapshot,
+	report *VerificationReport) *VerificationReport {
+	if sv.Replay != nil && report.Err == nil {
+		report.Err = sv.Replay.Accept(ctx, snapshot, report)
+	}
+	return report
+}
+
+/* prepare runs the checks that apply to the snapshot as a whole and
+returns the context its proofs are checked in */
+func (sv *SnapshotVerifier) prepare(snapshot *SimpleSnapshot) (*snapshotContext, error) {
+	if err := sv.checkNetwork(snapshot); err != nil {
+		return nil, err
+	}
+	if tx := snapshot.GetTransaction(); tx.GetId() != "" || sv.RequireTransactionId {
+		if err := tx.CheckId(); err != nil {
+			return nil, err
+		}
+	}
+	return newSnapshotContext(snapshot, sv.AllowLegacy)
+}
+
+/* checkNetwork returns a NetworkErr unless both the snapshot and its
+transaction belong to the verifier's network */
+func (sv *SnapshotVerifier) checkNetwork(snapshot *SimpleSnapshot) error {
+	network := snapshot.GetNetwork()
+	txNetwork := snapshot.GetTransaction().GetNetwork()
+	if network != sv.Network || txNetwork != sv.Network {
+		err := fmt.Errorf("snapshot %q, transaction %q, expected %q", network, txNetwork, sv.Network)
+		return &NetworkErr{simpleErr{err: err, msg: "Network ID mismatch"}}
+	}
+	return nil
+}
+
+/* snapshotContext holds the values that every proof of a snapshot is
+checked against. It is read only once created */
+type snapshotContext struct {
+	// hash is the snapshot's hash, used by proofs that don't record one
+	hash    crypto.Hash
+	network string
+	// txDigests holds the transaction digest for every hash proofs use
+	txDigests map[crypto.Hash][]byte
+	// Only set when legacy proofs are allowed
+	legacyTxDigest []byte
+}
+
+/* newSnapshotContext computes the shared verification values for a
+snapshot */
+func newSnapshotContext(snapshot *SimpleSnapshot, legacy bool) (*snapshotContext, error) {
+	hash, err := snapshot.GetHash()
+	if err != nil {
+		return nil, err
+	}
+
+	tx := snapshot.GetTransaction()
+	sc := &snapshotContext{hash: hash, network: snapshot.GetNetwork(), txDigests: make(map[crypto.Hash][]byte)}
+	hashes := []crypto.Hash{hash}
+	for _, proof := range snapshot.GetProofs() {
+		// Unknown hashes fail when their proof is checked
+		if proofHash, err := HashFromID(proof.GetHashID()); err == nil && proof.GetHashID() != "" {
+			hashes = append(hashes, proofHash)
+		}
+	}
+	for _, h := range hashes {
+		if _, ok := sc.txDigests[h]; ok {
+			continue
+		}
+		sc.txDigests[h], err = transactionDigest(tx, h)
+		if err != nil {
+			return nil, &DigestErr{simpleErr{err: err, msg: "newSnapshotContext()"}}
+		}
+	}
+	if legacy {
+		sc.legacyTxDigest, err = legacyDigestMarshaler(tx, hash)
+		if err != nil {
+			return nil, &DigestErr{simpleErr{err: err, msg: "newSnapshotContext()"}}
+		}
+	}
+	return sc, nil
+}
+
+/* proofDigest returns the hash a proof was signed with and the digest of
+the snapshot's transaction under that hash */
+func (sc *snapshotContext) proofDigest(proof *SimpleProofTuple) (crypto.Hash, []byte, error) {
+	if proof.GetHashID() == "" {
+		return sc.hash, sc.txDigests[sc.hash], nil
+	}
+	hash, err := HashFromID(proof.GetHashID())
+	if err != nil {
+		return 0, nil, err
+	}
+	return hash, sc.txDigests[hash], nil
+}
+
+/* proofStatement identifies what the signatures of a proof commit to
+within a snapshot, its epoch and the hash they were made with. Valid
+proofs with the same statement only differ in their signature bytes */
+type proofStatement struct {
+	hash  crypto.Hash
+	epoch string
+}
+
+// statement returns the proofStatement of a proof checked in sc
+func (sc *snapshotContext) statement(proof *SimpleProofTuple) (proofStatement, error) {
+	hash, _, err := sc.proofDigest(proof)
+	if err != nil {
+		return proofStatement{}, err
+	}
+	epoch, err := proof.GetEpoch().MarshalCanonical()
+	if err != nil {
+		return proofStatement{}, err
+	}
+	return proofStatement{hash: hash, epoch: string(epoch)}, nil
 }
 
 /* verifyProofComponents does the heavy lifting for VerifySnapshot by
-verifying the individual SimpleProofTuples */
+verifying the individual SimpleProofTuples. It returns the results of
+the transaction and epoch signature checks. Epoch signatures are checked
+against the snapshot's transaction digest and network so signatures
+taken from another snapshot are rejected */
 func verifyProofComponents(proof *SimpleProofTuple, pk crypto.PublicKey, verf Verifier,
-	transDigest []byte) error {
-
-	/* Don't need error because verification will fail anyway if the signature
-	is empty */
-	tSig, _ := base64.StdEncoding.DecodeString(proof.GetTransactionSignature())
-	err := verf(pk, ProofHashFunc, transDigest, tSig)
+	sc *snapshotContext) (tErr error, eErr error) {
+	hash, txDigest, err := sc.proofDigest(proof)
 	if err != nil {
-		return &VerificationErr{simpleErr{err: err, msg: "verifyProofComponents()"}}
+		return err, err
 	}
+	tErr = verifySignature(pk, verf, hash, txDigest, proof.GetTransactionSignature)
+	eDigest, err := epochDigest(proof.GetEpoch(), hash, sc.network, txDigest)
+	if err != nil {
+		return tErr, &DigestErr{simpleErr{err: err, msg: "verifyProofComponents()"}}
+	}
+	return tErr, verifySignature(pk, verf, hash, eDigest, proof.GetEpochSignature)
+}
 
-	eDigest, err := digestMarshaler(proof.GetEpoch())
+/* verifyLegacyProof verifies a SimpleProofTuple that was signed over the
+truncated digests of older releases. Those releases signed the bare epoch
+triplet without binding it to the transaction */
+func verifyLegacyProof(proof *SimpleProofTuple, pk crypto.PublicKey, verf Verifier,
+	sc *snapshotContext) (tErr error, eErr error) {
+	tErr = verifySignature(pk, verf, sc.hash, sc.legacyTxDigest, proof.GetTransactionSignature)
+	eDigest, err := legacyDigestMarshaler(proof.GetEpoch(), sc.hash)
 	if err != nil {
-		return &DigestErr{simpleErr{err: err, msg: "VerifySnapshot()"}}
+		return tErr, &DigestErr{simpleErr{err: err, msg: "verifyLegacyProof()"}}
 	}
+	return tErr, verifySignature(pk, verf, sc.hash, eDigest, proof.GetEpochSignature)
+}
 
-	eSig, _ := base64.StdEncoding.DecodeString(proof.GetEpochSignature())
-	err = verf(pk, ProofHashFunc, eDigest, eSig)
+/* verifySignature checks a proof signature against an already computed
+digest. Signatures that can't be decoded fail with the
+MalformedSignatureErr returned by signature */
+func verifySignature(pk crypto.PublicKey, verf Verifier, hash crypto.Hash, digest []byte,
+	signature func() ([]byte, error)) error {
+	if missingKey(pk) {
+		return unknownSignerErr("")
+	}
+	sig, err := signature()
 	if err != nil {
-		return &VerificationErr{simpleErr{err: err, msg: "verifyProofComponents()"}}
+		return err
+	}
+	if err := verf(pk, hash, digest, sig); err != nil {
+		return &VerificationErr{simpleErr{err: err, msg: "verifySignature()"}}
 	}
 	return nil
 }
 
 /* didPass runs the final check for VerifySnapshot to see whether the
-percentage of valid proofs was greater than the pass parameter */
-func didPass(pass float64, totalPass int, total int) error {
-	passStat := float64(totalPass) / float64(total)
+percentage of valid proofs was greater than the pass parameter. Both
+counts are proof weights, which are 1 per proof unless a WeightFunc is
+set */
+func didPass(pass float64, totalPass float64, total float64) error {
+	if total <= 0 {
+		return &PassErr{simpleErr{err: nil, msg: "No proof weight in VerifySnapshot"}}
+	}
+	passStat := totalPass / total
 	if passStat < pass {
 		return &PassErr{simpleErr{err: nil, msg: "Not enough passes in VerifySnapshot"}}
 	}
 	return nil
 }
+
+/* missingKey returns whether pk holds no key at all, including typed nil
+pointers and empty key slices */
+func missingKey(pk crypto.PublicKey) bool {
+	if pk == nil {
+		return true
+	}
+	v := reflect.ValueOf(pk)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map:
+		return v.IsNil()
+	case reflect.Slice:
+		return v.Len() == 0
+	}
+	return false
+}
+
+// unknownSignerErr builds the error for a node without a known key
+func unknownSignerErr(id string) error {
+	return &UnknownSignerErr{simpleErr{err: fmt.Errorf("%q", id), msg: "No public key for node"}}
+}
@@ -0,0 +1,247 @@
+package snapshot
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+/* Signature scheme IDs recorded by each SimpleProofTuple. The scheme
+decides which built-in verifier checks the proof and which keys it may
+be checked with */
+const (
+	SchemeEd25519     = "ed25519"
+	SchemeECDSAP256   = "ecdsa-p256"
+	SchemeECDSAP384   = "ecdsa-p384"
+	SchemeRSAPSS      = "rsa-pss"
+	SchemeRSAPKCS1v15 = "rsa-pkcs1v15"
+)
+
+// signatureScheme pairs a scheme's Verifier with the keys it accepts
+type signatureScheme struct {
+	verify  Verifier
+	accepts func(key crypto.PublicKey) bool
+}
+
+var signatureSchemes = map[string]signatureScheme{
+	SchemeEd25519:     {verify: Ed25519Verifier, accepts: isEd25519Key},
+	SchemeECDSAP256:   {verify: ECDSAVerifier, accepts: isECDSAKey(elliptic.P256())},
+	SchemeECDSAP384:   {verify: ECDSAVerifier, accepts: isECDSAKey(elliptic.P384())},
+	SchemeRSAPSS:      {verify: RSAPSSVerifier, accepts: isRSAKey},
+	SchemeRSAPKCS1v15: {verify: RSAPKCS1v15Verifier, accepts: isRSAKey},
+}
+
+/* schemeOf returns the signature scheme signer produces, or the empty
+string if the signer's key isn't supported by a built-in verifier. RSA
+keys sign with RSASSA-PKCS1-v1_5 unless wrapped in a PSSSigner */
+func schemeOf(signer crypto.Signer) string {
+	switch signer.(type) {
+	case PSSSigner, *PSSSigner:
+		return SchemeRSAPSS
+	}
+	pk := signer.Public()
+	switch {
+	case isEd25519Key(pk):
+		return SchemeEd25519
+	case isECDSAKey(elliptic.P256())(pk):
+		return SchemeECDSAP256
+	case isECDSAKey(elliptic.P384())(pk):
+		return SchemeECDSAP384
+	case isRSAKey(pk):
+		return SchemeRSAPKCS1v15
+	}
+	return ""
+}
+
+/* schemeVerifier returns the Verifier for a signature scheme after
+making sure the scheme may be used with key */
+func schemeVerifier(scheme string, key crypto.PublicKey) (Verifier, error) {
+	s, ok := signatureSchemes[scheme]
+	if !ok {
+		return nil, schemeErr("unknown signature scheme %q", scheme)
+	}
+	if !s.accepts(key) {
+		return nil, schemeErr("signature scheme %q can't be used with a %T key", scheme, key)
+	}
+	return s.verify, nil
+}
+
+// isEd25519Key returns whether key is a well formed Ed25519 key
+func isEd25519Key(key crypto.PublicKey) bool {
+	pk, ok := key.(ed25519.PublicKey)
+	return ok && len(pk) == ed25519.PublicKeySize
+}
+
+// isECDSAKey returns a function reporting whether a key is on curve
+func isECDSAKey(curve elliptic.Curve) func(key crypto.PublicKey) bool {
+	return func(key crypto.PublicKey) bool {
+		pk, ok := key.(*ecdsa.PublicKey)
+		return ok && pk != nil && pk.Curve == curve
+	}
+}
+
+// isRSAKey returns whether key is an RSA key
+func isRSAKey(key crypto.PublicKey) bool {
+	pk, ok := key.(*rsa.PublicKey)
+	return ok && pk != nil
+}
+
+/* Ed25519Verifier is a Verifier for ed25519.PublicKey keys. Ed25519
+signs a message rather than a pre-hashed digest, so the digest itself is
+treated as the signed message and hash is ignored */
+func Ed25519Verifier(key crypto.PublicKey, hash crypto.Hash, digest []byte, sig []byte) error {
+	if !isEd25519Key(key) {
+		return keyTypeErr("ed25519.PublicKey", key)
+	}
+	if !ed25519.Verify(key.(ed25519.PublicKey), digest, sig) {
+		return errors.New("ed25519: invalid signature")
+	}
+	return nil
+}
+
+/* ECDSAVerifier is a Verifier for *ecdsa.PublicKey keys on the P-256 and
+P-384 curves. Signatures are ASN.1 DER encoded and must have an s value
+in the lower half of the curve order, as written by NewSimpleProofTuple,
+so a valid signature can't be altered into a second valid one */
+func ECDSAVerifier(key crypto.PublicKey, hash crypto.Hash, digest []byte, sig []byte) error {
+	pk, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return keyTypeErr("*ecdsa.PublicKey", key)
+	}
+	if pk.Curve != elliptic.P256() && pk.Curve != elliptic.P384() {
+		return fmt.Errorf("ecdsa: unsupported curve %s", pk.Curve.Params().Name)
+	}
+	parsed, err := parseECDSASignature(sig)
+	if err != nil {
+		return err
+	}
+	if parsed.S.Cmp(halfOrder(pk.Curve)) > 0 {
+		return errors.New("ecdsa: signature s value is not in the lower half of the curve order")
+	}
+	if !ecdsa.VerifyASN1(pk, digest, sig) {
+		return errors.New("ecdsa: invalid signature")
+	}
+	return nil
+}
+
+/* RSAPSSVerifier is a Verifier for *rsa.PublicKey keys signing with
+RSASSA-PSS. Any salt length is accepted */
+func RSAPSSVerifier(key crypto.PublicKey, hash crypto.Hash, digest []byte, sig []byte) error {
+	pk, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return keyTypeErr("*rsa.PublicKey", key)
+	}
+	return rsa.VerifyPSS(pk, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+}
+
+/* RSAPKCS1v15Verifier is a Verifier for *rsa.PublicKey keys signing with
+RSASSA-PKCS1-v1_5, which is what *rsa.PrivateKey produces by default */
+func RSAPKCS1v15Verifier(key crypto.PublicKey, hash crypto.Hash, digest []byte, sig []byte) error {
+	pk, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return keyTypeErr("*rsa.PublicKey", key)
+	}
+	return rsa.VerifyPKCS1v15(pk, hash, digest, sig)
+}
+
+/* KeyTypeVerifier is a Verifier that picks one of the built-in verifiers
+based on the concrete type of the key. RSA keys are checked with
+RSAPKCS1v15Verifier, which is how *rsa.PrivateKey and releases that
+didn't record a scheme sign. Proofs signed with PSSSigner record their
+scheme and are checked with RSAPSSVerifier without reaching it */
+func KeyTypeVerifier(key crypto.PublicKey, hash crypto.Hash, digest []byte, sig []byte) error {
+	switch key.(type) {
+	case ed25519.PublicKey:
+		return Ed25519Verifier(key, hash, digest, sig)
+	case *ecdsa.PublicKey:
+		return ECDSAVerifier(key, hash, digest, sig)
+	case *rsa.PublicKey:
+		return RSAPKCS1v15Verifier(key, hash, digest, sig)
+	}
+	return keyTypeErr("a supported key", key)
+}
+
+/* PSSSigner wraps an *rsa.PrivateKey so it signs with RSASSA-PSS instead
+of RSASSA-PKCS1-v1_5. Use it with RSAPSSVerifier */
+type PSSSigner struct {
+	*rsa.PrivateKey
+}
+
+// Sign signs digest with RSASSA-PSS using a salt as long as the hash
+func (ps PSSSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return ps.PrivateKey.Sign(rand, digest, &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthEqualsHash,
+		Hash:       opts.HashFunc(),
+	})
+}
+
+/* signDigest signs a digest computed with hash. Ed25519 keys refuse
+pre-hashed input, so they sign the digest as a plain message instead.
+ECDSA signatures are normalized to the low s form ECDSAVerifier accepts */
+func signDigest(signer crypto.Signer, digest []byte, hash crypto.Hash) ([]byte, error) {
+	var opts crypto.SignerOpts = hash
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		opts = crypto.Hash(0)
+	}
+	sig, err := signer.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	if pk, ok := signer.Public().(*ecdsa.PublicKey); ok {
+		return lowSSignature(pk, sig)
+	}
+	return sig, nil
+}
+
+// ecdsaSignature is the ASN.1 structure of an ECDSA signature
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// parseECDSASignature decodes an ASN.1 DER encoded ECDSA signature
+func parseECDSASignature(sig []byte) (*ecdsaSignature, error) {
+	parsed := &ecdsaSignature{}
+	rest, err := asn1.Unmarshal(sig, parsed)
+	if err != nil || len(rest) != 0 {
+		return nil, errors.New("ecdsa: malformed signature")
+	}
+	return parsed, nil
+}
+
+/* halfOrder returns half the order of curve. Both (r, s) and (r, n-s)
+are valid signatures, only the one with s at most this is accepted */
+func halfOrder(curve elliptic.Curve) *big.Int {
+	return new(big.Int).Rsh(curve.Params().N, 1)
+}
+
+/* lowSSignature returns sig with its s value replaced by n-s if it is in
+the upper half of the curve order */
+func lowSSignature(pk *ecdsa.PublicKey, sig []byte) ([]byte, error) {
+	parsed, err := parseECDSASignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.S.Cmp(halfOrder(pk.Curve)) <= 0 {
+		return sig, nil
+	}
+	parsed.S.Sub(pk.Curve.Params().N, parsed.S)
+	return asn1.Marshal(*parsed)
+}
+
+// schemeErr builds a SchemeErr from a formatted message
+func schemeErr(format string, args ...interface{}) error {
+	return &SchemeErr{simpleErr{err: fmt.Errorf(format, args...), msg: "Signature scheme rejected"}}
+}
+
+// keyTypeErr builds the error for a key a verifier can't handle
+func keyTypeErr(expected string, key crypto.PublicKey) error {
+	return &KeyErr{simpleErr{err: fmt.Errorf("expected %s, got %T", expected, key), msg: "Unsupported key type"}}
+}
@@ -0,0 +1,157 @@
+package snapshot
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestBuiltinVerifiers(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p521, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		signer crypto.Signer
+		verf   Verifier
+		valid  bool
+	}{
+		{"ed25519", edKey, Ed25519Verifier, true},
+		{"ecdsa p256", p256, ECDSAVerifier, true},
+		{"ecdsa p384", p384, ECDSAVerifier, true},
+		{"ecdsa p521", p521, ECDSAVerifier, false},
+		{"rsa pkcs1v15", testKey(t, 0), RSAPKCS1v15Verifier, true},
+		{"rsa pss", PSSSigner{testKey(t, 0)}, RSAPSSVerifier, true},
+		{"rsa pss as pkcs1v15", PSSSigner{testKey(t, 0)}, RSAPKCS1v15Verifier, false},
+		{"dispatch ed25519", edKey, KeyTypeVerifier, true},
+		{"dispatch ecdsa", p256, KeyTypeVerifier, true},
+		{"dispatch rsa", testKey(t, 0), KeyTypeVerifier, true},
+		{"dispatch rsa pss", PSSSigner{testKey(t, 0)}, KeyTypeVerifier, false},
+		{"wrong key type", testKey(t, 0), Ed25519Verifier, false},
+	}
+	for _, c := range cases {
+		tx := createTransaction(1, 1, 10, "ID1", "ID2")
+		snapshot := NewSimpleSnapshot(tx)
+		tup, err := NewSimpleProofTuple(tx, "0", 1, 5, c.signer)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		// Without a scheme the proof is checked with the given Verifier
+		tup.protoProofTuple.Scheme = ""
+		snapshot.AddProof(tup)
+
+		keys := map[string]crypto.PublicKey{"0": c.signer.Public()}
+		if err := VerifySnapshot(1, snapshot, keys, c.verf); (err == nil) != c.valid {
+			t.Errorf("%s: expected valid=%v, got %v", c.name, c.valid, err)
+		}
+	}
+
+	// Legacy proofs never record a scheme and were signed with RSASSA-PKCS1-v1_5
+	tx := createTransaction(1, 1, 10, "ID1", "ID2")
+	legacy := NewSimpleSnapshot(tx)
+	legacy.AddProof(legacyProofTuple(t, tx, "0", 1, 5, testKey(t, 0)))
+	sv := &SnapshotVerifier{Pass: 1, Keys: MapKeyResolver{"0": &testKey(t, 0).PublicKey}, Verifier: KeyTypeVerifier,
+		AllowLegacy: true}
+	if err := sv.Verify(legacy); err != nil {
+		t.Errorf("legacy RSA proof rejected by KeyTypeVerifier: %v", err)
+	}
+
+	var keyErr *KeyErr
+	if err := KeyTypeVerifier("not a key", crypto.SHA256, nil, nil); !errors.As(err, &keyErr) {
+		t.Errorf("expected KeyErr for an unsupported key, got %v", err)
+	}
+}
+
+func TestProofSchemes(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := []crypto.Signer{edKey, ecKey, testKey(t, 0), PSSSigner{testKey(t, 1)}}
+	schemes := []string{SchemeEd25519, SchemeECDSAP256, SchemeRSAPKCS1v15, SchemeRSAPSS}
+
+	tx := createTransaction(1, 1, 10, "ID1", "ID2")
+	build := func() (*SimpleSnapshot, map[string]crypto.PublicKey) {
+		snapshot := NewSimpleSnapshot(tx)
+		keys := make(map[string]crypto.PublicKey)
+		for i, signer := range signers {
+			id := strconv.Itoa(i)
+			tup, err := NewSimpleProofTuple(tx, id, 1, 5, signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tup.GetScheme() != schemes[i] {
+				t.Fatalf("signer %d recorded scheme %q, expected %q", i, tup.GetScheme(), schemes[i])
+			}
+			snapshot.AddProof(tup)
+			keys[id] = signer.Public()
+		}
+		return snapshot, keys
+	}
+
+	// One snapshot mixes every scheme and needs no Verifier
+	snapshot, keys := build()
+	sv := &SnapshotVerifier{Pass: 1, Keys: MapKeyResolver(keys)}
+	if err := sv.Verify(snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	// The scheme isn't signed, stripping it must not reach a nil Verifier
+	var schemeErr *SchemeErr
+	snapshot.protoSnapshot.Proofs[0].Scheme = ""
+	report := sv.Report(snapshot)
+	if !errors.As(report.Proofs[0].TransactionErr, &schemeErr) || report.Passed != 3 {
+		t.Fatalf("expected SchemeErr for a stripped scheme without a Verifier, got %v", report.Proofs[0].TransactionErr)
+	}
+
+	snapshot, _ = build()
+	snapshot.protoSnapshot.Proofs[2].Scheme = SchemeEd25519
+	snapshot.protoSnapshot.Proofs[3].Scheme = SchemeRSAPKCS1v15
+	report = sv.Report(snapshot)
+	if !errors.As(report.Proofs[2].TransactionErr, &schemeErr) {
+		t.Fatalf("expected SchemeErr for a scheme that doesn't fit the key, got %v", report.Proofs[2].TransactionErr)
+	}
+	if report.Proofs[3].Valid() {
+		t.Fatal("RSA-PSS signatures verified as RSASSA-PKCS1-v1_5")
+	}
+	if report.Passed != 2 {
+		t.Fatalf("expected 2 valid proofs, got %d", report.Passed)
+	}
+
+	snapshot, _ = build()
+	snapshot.protoSnapshot.Proofs[0].Scheme = ""
+	sv.Verifier = KeyTypeVerifier
+	sv.AllowedSchemes = []string{SchemeEd25519, SchemeECDSAP256, SchemeRSAPSS}
+	report = sv.Report(snapshot)
+	for i, valid := range []bool{false, true, false, true} {
+		if report.Proofs[i].Valid() != valid {
+			t.Errorf("proof %d: expected valid=%v, got %v", i, valid, report.Proofs[i].TransactionErr)
+		}
+	}
+	if !errors.As(report.Proofs[0].TransactionErr, &schemeErr) {
+		t.Fatalf("expected SchemeErr for a proof without a scheme, got %v", report.Proofs[0].TransactionErr)
+	}
+}
@@ -0,0 +1,49 @@
+package snapshot
+
+import (
+	"math"
+	"reflect"
+)
+
+/* WeightFunc returns how much a proof counts towards the Pass quorum of
+a SnapshotVerifier. Negative and non-finite weights count as zero. A
+WeightFunc is assumed to know each node's weight independently of its
+proofs, so a node that fails still counts with its full weight */
+type WeightFunc func(epoch *SimpleEpochTriplet) float64
+
+/* AttestedBalanceWeight weighs each proof by the balance it attests.
+The balance is chosen by the proving node itself, so only use it when
+the proving nodes are trusted to report their stake honestly. A node
+only weighs what one of its proofs with a valid epoch signature
+attests, so forged proofs can't add weight */
+func AttestedBalanceWeight(epoch *SimpleEpochTriplet) float64 {
+	return epoch.GetBalance()
+}
+
+/* KnownBalanceWeight returns a WeightFunc that weighs each proof by an
+independently known balance for its Node Id. Nodes missing from the
+map carry no weight */
+func KnownBalanceWeight(balances map[string]float64) WeightFunc {
+	return func(epoch *SimpleEpochTriplet) float64 {
+		return balances[epoch.GetId()]
+	}
+}
+
+/* proofWeight returns the weight of a proof under weigh. Without a
+WeightFunc every proof weighs 1 */
+func proofWeight(weigh WeightFunc, epoch *SimpleEpochTriplet) float64 {
+	if weigh == nil {
+		return 1
+	}
+	weight := weigh(epoch)
+	if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return 0
+	}
+	return weight
+}
+
+/* attestsWeight returns whether weigh takes the weight from the proof
+itself, in which case only a valid epoch signature vouches for it */
+func attestsWeight(weigh WeightFunc) bool {
+	return weigh != nil && reflect.ValueOf(weigh).Pointer() == reflect.ValueOf(AttestedBalanceWeight).Pointer()
+}